@@ -3,10 +3,9 @@ module github.com/mitch000001/fitbit-exporter
 go 1.16
 
 require (
-	github.com/google/uuid v1.2.0
-	github.com/kr/pretty v0.1.0
 	github.com/pkg/errors v0.9.1
 	github.com/prometheus/client_golang v1.11.0
+	github.com/prometheus/client_model v0.2.0
 	golang.org/x/oauth2 v0.0.0-20210514164344-f6687ab2804c
 	golang.org/x/time v0.0.0-20210723032227-1f47c861a9ac
 )
@@ -10,11 +10,11 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
-	"github.com/google/uuid"
-	"github.com/kr/pretty"
 	"github.com/mitch000001/fitbit-exporter/pkg/fitbit"
 	"github.com/mitch000001/fitbit-exporter/pkg/http/handler"
 	"github.com/mitch000001/fitbit-exporter/pkg/http/oauth"
@@ -31,6 +31,8 @@ func init() {
 	prometheus.MustRegister(
 		clientRequestCounter, tlsLatencyVec, dnsLatencyVec, histVec, inFlightGauge,
 		rateLimiterLimitGauge, rateLimiterRemainingGauge, rateLimiterResetsAfterGauge,
+		rateLimitedCounter, rateLimiterDeferredCounter, rateLimiterDroppedCounter,
+		profileCollector,
 	)
 }
 
@@ -40,49 +42,116 @@ func main() {
 		RemainingKey:   "Fitbit-Rate-Limit-Remaining",
 		ResetsAfterKey: "Fitbit-Rate-Limit-Reset",
 	}
-	rl, err := rate.NewFromHeader(rateLimitHeaderKeys)
-	if err != nil {
-		log.Printf("Error initializing rate limiter: %v", err)
-		os.Exit(1)
+	rateLimit := rateLimitConfig{
+		headerKeys: rateLimitHeaderKeys,
+		reserve:    0.1,
+		dropLow:    os.Getenv("FITBIT_RATE_LIMIT_DROP_LOW_PRIORITY") == "true",
+		stateDir:   os.Getenv("FITBIT_RATE_LIMIT_STATE_DIR"),
+	}
+	var err error
+	if reserve := os.Getenv("FITBIT_RATE_LIMIT_RESERVE"); reserve != "" {
+		rateLimit.reserve, err = strconv.ParseFloat(reserve, 64)
+		if err != nil || rateLimit.reserve < 0 || rateLimit.reserve >= 1 {
+			log.Printf("Error parsing rate limit reserve %q: must be a fraction between 0 and 1", reserve)
+			os.Exit(1)
+		}
+	}
+	if rateLimit.stateDir != "" {
+		if err := os.MkdirAll(rateLimit.stateDir, 0700); err != nil {
+			log.Printf("Error creating rate limit state directory: %v", err)
+			os.Exit(1)
+		}
+	}
+	var leaderboardFriends []string
+	if friends := os.Getenv("FITBIT_LEADERBOARD_FRIENDS"); friends != "" {
+		for _, friend := range strings.Split(friends, ",") {
+			leaderboardFriends = append(leaderboardFriends, strings.TrimSpace(friend))
+		}
+	}
+	scrapeConf := scrapeConfig{
+		intradayDetailLevel: fitbit.DetailLevel1Min,
+		workoutLimit:        10,
+		leaderboardFriends:  leaderboardFriends,
 	}
-	tokenCache, err := oauth.NewJSONFileTokenCache(os.Getenv("OAUTH2_TOKEN_FILE"))
+	if detailLevel := os.Getenv("FITBIT_INTRADAY_DETAIL_LEVEL"); detailLevel != "" {
+		scrapeConf.intradayDetailLevel, err = fitbit.ParseDetailLevel(detailLevel)
+		if err != nil {
+			log.Printf("Error parsing intraday detail level: %v", err)
+			os.Exit(1)
+		}
+		if scrapeConf.intradayDetailLevel == fitbit.DetailLevel1Sec {
+			log.Printf("Intraday detail level %s is only supported for heart rate", scrapeConf.intradayDetailLevel)
+			os.Exit(1)
+		}
+	}
+	if workoutLimit := os.Getenv("FITBIT_WORKOUT_LIMIT"); workoutLimit != "" {
+		scrapeConf.workoutLimit, err = strconv.Atoi(workoutLimit)
+		if err != nil || scrapeConf.workoutLimit < 1 {
+			log.Printf("Error parsing workout limit %q: must be a positive number", workoutLimit)
+			os.Exit(1)
+		}
+	}
+	tokenDir := os.Getenv("OAUTH2_TOKEN_DIR")
+	if tokenDir == "" {
+		tokenDir = "tokens"
+	}
+	tokenStore, err := oauth.NewJSONFileTokenStore(tokenDir)
 	if err != nil {
-		log.Printf("Error initializing token cache: %v", err)
+		log.Printf("Error initializing token store: %v", err)
 		os.Exit(1)
 	}
 	conf := &oauth.Config{
-		State:               uuid.NewString(),
-		RateLimiter:         rl,
-		InstrumentTransport: instrumentTransport(rateLimitHeaderKeys),
+		States: oauth.NewStateStore(oauth.DefaultStateTTL),
 		Config: &oauth2.Config{
 			ClientID:     os.Getenv("OAUTH2_CLIENT_ID"),
 			ClientSecret: os.Getenv("OAUTH2_CLIENT_SECRET"),
 			RedirectURL:  os.Getenv("OAUTH2_REDIRECT_URL"),
 			Scopes: []string{
 				"activity",
+				"cardio_fitness",
 				"heartrate",
 				"location",
 				"nutrition",
+				"oxygen_saturation",
 				"profile",
+				"respiratory_rate",
 				"settings",
 				"sleep",
 				"social",
+				"temperature",
 				"weight",
 			},
 			Endpoint: oauth_fitbit.Endpoint,
 		},
 	}
-	if err := conf.SetTokenCache(tokenCache); err != nil {
-		log.Printf("Error setting token cache: %v", err)
+
+	if conf.ClientSecret == "" {
+		// Apps of type client authenticate with PKCE only and send their
+		// client ID within the token request.
+		conf.Endpoint.AuthStyle = oauth2.AuthStyleInParams
+	}
+
+	users := newUserRegistry(conf, tokenStore, rateLimit, scrapeConf, prometheus.DefaultRegisterer)
+	if err := users.Load(); err != nil {
+		log.Printf("Error loading users: %v", err)
 		os.Exit(1)
 	}
+	if tokenFile := os.Getenv("OAUTH2_TOKEN_FILE"); tokenFile != "" {
+		if err := users.LoadTokenFile(context.Background(), tokenFile); err != nil {
+			log.Printf("Error loading user from token file: %v", err)
+			if errors.Is(err, oauth.ErrInsecureTokenFile) {
+				os.Exit(1)
+			}
+		}
+	}
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/auth", handler.OauthHandler())
 	mux.HandleFunc("/authorize", handler.AuthorizeHandler(conf))
-	mux.HandleFunc("/oauth-redirect", handler.OauthRedirectHandler(conf))
+	mux.HandleFunc("/oauth-redirect", handler.OauthRedirectHandler(conf, users.Enroll))
 	mux.Handle("/metrics", promhttp.Handler())
-	mux.HandleFunc("/", handler.AuthMiddleware(conf, handler.Handler()))
+	mux.HandleFunc("/status", handler.AuthMiddleware(users, handler.StatusHandler(users.Plans)))
+	mux.HandleFunc("/", handler.AuthMiddleware(users, handler.Handler()))
 	server := &http.Server{
 		Addr:    ":3000",
 		Handler: mux,
@@ -94,7 +163,12 @@ func main() {
 			log.Printf("Error starting listening server: %v", err)
 		}
 	}()
-	cancelFn := startMetricCollector(conf)
+	schedulerCtx, cancelFn := context.WithCancel(context.Background())
+	schedulerDone := make(chan struct{})
+	go func() {
+		users.Run(schedulerCtx)
+		close(schedulerDone)
+	}()
 	sigs := make(chan os.Signal, 1)
 	done := make(chan bool, 1)
 
@@ -110,6 +184,7 @@ func main() {
 			log.Printf("Error shutting down server: %v", err)
 		}
 		cancelFn()
+		<-schedulerDone
 		cancel()
 		done <- true
 	}()
@@ -119,63 +194,6 @@ func main() {
 
 }
 
-func startMetricCollector(clientProvider oauth.ClientProvider) func() {
-	cancel := make(chan bool, 1)
-	go func(clientProvider oauth.ClientProvider) {
-		for {
-			ctx := context.Background()
-			ctx, cancelFn := context.WithCancel(ctx)
-			select {
-			case <-cancel:
-				cancelFn()
-				return
-			case <-time.Tick(10 * time.Second):
-				scrapeMetrics(ctx, clientProvider)
-				cancelFn()
-			}
-		}
-	}(clientProvider)
-	return func() {
-		cancel <- true
-	}
-}
-
-func scrapeMetrics(ctx context.Context, clientProvider oauth.ClientProvider) {
-	client, err := clientProvider.Client(ctx)
-	if err != nil {
-		log.Printf("Error getting client: %v\n", err)
-		return
-	}
-
-	// res, err := client.PostForm("https://api.fitbit.com/1.1/oauth2/introspect", url.Values{"token": []string{tok.AccessToken}})
-	// if err != nil {
-	// 	log.Printf("Error introspecting token: %v", err)
-	// 	return
-	// }
-	// if err := printResponse(res); err != nil {
-	// 	log.Printf("Error reading token introspect response: %v", err)
-	// 	return
-	// }
-	now := time.Now()
-	now10Sec := now.Truncate(60 * time.Minute).Format("15:04")
-	time := now.Format("15:04")
-	res, err := client.Get(fmt.Sprintf("https://api.fitbit.com/1/user/-/activities/heart/date/today/1d/1sec/time/%s/%s.json", now10Sec, time))
-	if err != nil {
-		log.Printf("Error getting heartrate: %v", err)
-	}
-	var buf bytes.Buffer
-	var heartRates fitbit.HeartRateResult
-	if err := json.NewDecoder(io.TeeReader(res.Body, &buf)).Decode(&heartRates); err != nil {
-		log.Printf("Error parsing heartrate: %v", err)
-	}
-	pretty.Printf("Heartrates:\n%# v\n", heartRates)
-	// if err := printResponse(&buf); err != nil {
-	// 	log.Printf("Error reading profile: %v", err)
-	// 	return
-	// }
-	log.Println("Metrics scraped")
-}
-
 func printResponse(body io.Reader) error {
 	bodyBytes, err := io.ReadAll(body)
 	if err != nil {
@@ -4,6 +4,7 @@ import (
 	"log"
 	"net/http"
 
+	"github.com/mitch000001/fitbit-exporter/pkg/collector"
 	"github.com/mitch000001/fitbit-exporter/pkg/http/rate"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -81,26 +82,110 @@ var (
 		},
 	}
 
-	rateLimiterLimitGauge = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: "fitbit",
-		Name:      "rate_limiter_limit",
-		Help:      "A gauge of the max requests allowed by the API rate limit.",
-	})
+	// The rate limit metrics are labelled by user ID as Fitbit limits the
+	// requests per user.
+	rateLimiterLimitGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "fitbit",
+			Name:      "rate_limiter_limit",
+			Help:      "A gauge of the max requests allowed by the API rate limit.",
+		},
+		[]string{"user_id"},
+	)
 
-	rateLimiterRemainingGauge = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: "fitbit",
-		Name:      "rate_limiter_remaining",
-		Help:      "A gauge of the remaining requests allowed by the API rate limit.",
-	})
+	rateLimiterRemainingGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "fitbit",
+			Name:      "rate_limiter_remaining",
+			Help:      "A gauge of the remaining requests allowed by the API rate limit.",
+		},
+		[]string{"user_id"},
+	)
 
-	rateLimiterResetsAfterGauge = prometheus.NewGauge(prometheus.GaugeOpts{
-		Namespace: "fitbit",
-		Name:      "rate_limiter_reset_after_seconds",
-		Help:      "A gauge of the seconds after which the rate limit will be reset.",
-	})
+	rateLimiterResetsAfterGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "fitbit",
+			Name:      "rate_limiter_reset_after_seconds",
+			Help:      "A gauge of the seconds after which the rate limit will be reset.",
+		},
+		[]string{"user_id"},
+	)
+
+	rateLimitedCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "fitbit",
+			Name:      "rate_limited_total",
+			Help:      "A counter of responses rejected by the API rate limit.",
+		},
+		[]string{"user_id"},
+	)
+
+	rateLimiterDeferredCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "fitbit",
+			Name:      "rate_limiter_deferred_total",
+			Help:      "A counter of requests deferred by the rate limiter to keep the reserve for higher priorities.",
+		},
+		[]string{"user_id", "priority"},
+	)
+
+	rateLimiterDroppedCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "fitbit",
+			Name:      "rate_limiter_dropped_total",
+			Help:      "A counter of requests dropped by the rate limiter to keep the reserve for higher priorities.",
+		},
+		[]string{"user_id", "priority"},
+	)
+
+	profileCollector = collector.NewProfile()
 )
 
-func instrumentTransport(rateLimitHeaderKeys rate.HeaderKeys) func(t http.RoundTripper) http.RoundTripper {
+// healthCollectors holds the collectors exposing the health data of a single
+// user.
+type healthCollectors struct {
+	heartRate         *collector.HeartRate
+	activeZoneMinutes *collector.ActiveZoneMinutes
+	sleep             *collector.Sleep
+	activity          *collector.Activity
+	intraday          *collector.Intraday
+	body              *collector.Body
+	devices           *collector.Devices
+	nutrition         *collector.Nutrition
+	health            *collector.Health
+	workouts          *collector.Workouts
+	lifetime          *collector.Lifetime
+	leaderboard       *collector.Leaderboard
+}
+
+func newHealthCollectors(leaderboardFriends []string) *healthCollectors {
+	return &healthCollectors{
+		heartRate:         collector.NewHeartRate(),
+		activeZoneMinutes: collector.NewActiveZoneMinutes(),
+		sleep:             collector.NewSleep(),
+		activity:          collector.NewActivity(),
+		intraday:          collector.NewIntraday(),
+		body:              collector.NewBody(),
+		devices:           collector.NewDevices(),
+		nutrition:         collector.NewNutrition(),
+		health:            collector.NewHealth(),
+		workouts:          collector.NewWorkouts(),
+		lifetime:          collector.NewLifetime(),
+		leaderboard:       collector.NewLeaderboard(leaderboardFriends),
+	}
+}
+
+// all returns all collectors to register them.
+func (c *healthCollectors) all() []prometheus.Collector {
+	return []prometheus.Collector{
+		c.heartRate, c.sleep, c.activity, c.intraday,
+		c.body, c.devices, c.nutrition, c.health,
+		c.activeZoneMinutes, c.workouts, c.lifetime,
+		c.leaderboard,
+	}
+}
+
+func instrumentTransport(rateLimitHeaderKeys rate.HeaderKeys, userID string, observeLimit func(rate.Limit)) func(t http.RoundTripper) http.RoundTripper {
 	return func(t http.RoundTripper) http.RoundTripper {
 		return promhttp.InstrumentRoundTripperInFlight(
 			inFlightGauge,
@@ -111,10 +196,11 @@ func instrumentTransport(rateLimitHeaderKeys rate.HeaderKeys) func(t http.RoundT
 					promhttp.InstrumentRoundTripperDuration(
 						histVec,
 						instrumentRoundTripperRateLimitHeader(
-							rateLimiterLimitGauge,
-							rateLimiterRemainingGauge,
-							rateLimiterResetsAfterGauge,
+							rateLimiterLimitGauge.WithLabelValues(userID),
+							rateLimiterRemainingGauge.WithLabelValues(userID),
+							rateLimiterResetsAfterGauge.WithLabelValues(userID),
 							rateLimitHeaderKeys,
+							observeLimit,
 							t,
 						),
 					),
@@ -124,9 +210,12 @@ func instrumentTransport(rateLimitHeaderKeys rate.HeaderKeys) func(t http.RoundT
 	}
 }
 
-func instrumentRoundTripperRateLimitHeader(limitGauge, remainingGauge, resetAfterSecondsGauge prometheus.Gauge, rateLimitHeaders rate.HeaderKeys, next http.RoundTripper) promhttp.RoundTripperFunc {
+func instrumentRoundTripperRateLimitHeader(limitGauge, remainingGauge, resetAfterSecondsGauge prometheus.Gauge, rateLimitHeaders rate.HeaderKeys, observeLimit func(rate.Limit), next http.RoundTripper) promhttp.RoundTripperFunc {
 	return promhttp.RoundTripperFunc(func(r *http.Request) (*http.Response, error) {
 		response, err := next.RoundTrip(r)
+		if err != nil {
+			return response, err
+		}
 		limit, lerr := rate.LimitFromHeader(response.Header, rateLimitHeaders)
 		if lerr != nil {
 			log.Printf("Error getting rate limit: %v", lerr)
@@ -135,6 +224,9 @@ func instrumentRoundTripperRateLimitHeader(limitGauge, remainingGauge, resetAfte
 		limitGauge.Set(float64(limit.Limit))
 		remainingGauge.Set(float64(limit.Remaining))
 		resetAfterSecondsGauge.Set(float64(limit.ResetAfterSeconds))
+		if observeLimit != nil {
+			observeLimit(limit)
+		}
 		return response, err
 	})
 }
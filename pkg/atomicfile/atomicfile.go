@@ -0,0 +1,48 @@
+// Package atomicfile writes files atomically, so a crash never leaves a
+// truncated file behind.
+package atomicfile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// WriteFile writes the data to a temporary file only readable by its owner
+// and renames it to filePath once it is synced to disk.
+func WriteFile(filePath string, data []byte) error {
+	dir, name := filepath.Split(filePath)
+	if dir == "" {
+		dir = "."
+	}
+	file, err := os.CreateTemp(dir, "."+name+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("error creating temporary file: %w", err)
+	}
+	tmpPath := file.Name()
+	defer os.Remove(tmpPath)
+	if err := file.Chmod(0600); err != nil {
+		file.Close()
+		return fmt.Errorf("error setting file permissions: %w", err)
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return fmt.Errorf("error writing temporary file: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("error syncing temporary file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("error closing temporary file: %w", err)
+	}
+	if err := os.Rename(tmpPath, filePath); err != nil {
+		return fmt.Errorf("error renaming temporary file: %w", err)
+	}
+	// Sync the directory, so the rename survives a crash as well.
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
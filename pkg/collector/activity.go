@@ -0,0 +1,142 @@
+package collector
+
+import (
+	"sync"
+
+	"github.com/mitch000001/fitbit-exporter/pkg/fitbit"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Activity is a prometheus.Collector exposing the most recently fetched
+// daily activity summary.
+type Activity struct {
+	mutex  sync.Mutex
+	result *fitbit.ActivitySummaryResult
+
+	steps            *prometheus.Desc
+	distance         *prometheus.Desc
+	floors           *prometheus.Desc
+	elevation        *prometheus.Desc
+	caloriesOut      *prometheus.Desc
+	caloriesBMR      *prometheus.Desc
+	activityCalories *prometheus.Desc
+	activeMinutes    *prometheus.Desc
+	goal             *prometheus.Desc
+	goalProgress     *prometheus.Desc
+}
+
+func NewActivity() *Activity {
+	return &Activity{
+		steps: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "steps"),
+			"The steps taken today.",
+			nil, nil,
+		),
+		distance: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "distance_meters"),
+			"The distance covered today by activity type in meters.",
+			[]string{"activity"}, nil,
+		),
+		floors: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "floors"),
+			"The floors climbed today.",
+			nil, nil,
+		),
+		elevation: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "elevation_meters"),
+			"The elevation climbed today in meters.",
+			nil, nil,
+		),
+		caloriesOut: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "calories_out"),
+			"The total calories burned today.",
+			nil, nil,
+		),
+		caloriesBMR: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "calories_bmr"),
+			"The calories burned today by the basal metabolic rate.",
+			nil, nil,
+		),
+		activityCalories: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "activity_calories"),
+			"The calories burned today by activities.",
+			nil, nil,
+		),
+		activeMinutes: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "active_minutes"),
+			"The minutes spent today by intensity.",
+			[]string{"intensity"}, nil,
+		),
+		goal: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "daily_goal"),
+			"The daily activity goals. Distances are given in meters.",
+			[]string{"goal"}, nil,
+		),
+		goalProgress: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "goal_progress_ratio"),
+			"The progress towards the daily activity goals as ratio.",
+			[]string{"goal"}, nil,
+		),
+	}
+}
+
+// Update replaces the activity summary exposed by the collector.
+func (c *Activity) Update(result fitbit.ActivitySummaryResult) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.result = &result
+}
+
+func (c *Activity) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.steps
+	ch <- c.distance
+	ch <- c.floors
+	ch <- c.elevation
+	ch <- c.caloriesOut
+	ch <- c.caloriesBMR
+	ch <- c.activityCalories
+	ch <- c.activeMinutes
+	ch <- c.goal
+	ch <- c.goalProgress
+}
+
+func (c *Activity) Collect(ch chan<- prometheus.Metric) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.result == nil {
+		return
+	}
+	summary := c.result.Summary
+	ch <- prometheus.MustNewConstMetric(c.steps, prometheus.GaugeValue, float64(summary.Steps))
+	for _, distance := range summary.Distances {
+		ch <- prometheus.MustNewConstMetric(c.distance, prometheus.GaugeValue, distance.Distance*1000, distance.Activity)
+	}
+	ch <- prometheus.MustNewConstMetric(c.floors, prometheus.GaugeValue, float64(summary.Floors))
+	ch <- prometheus.MustNewConstMetric(c.elevation, prometheus.GaugeValue, summary.Elevation)
+	ch <- prometheus.MustNewConstMetric(c.caloriesOut, prometheus.GaugeValue, float64(summary.CaloriesOut))
+	ch <- prometheus.MustNewConstMetric(c.caloriesBMR, prometheus.GaugeValue, float64(summary.CaloriesBMR))
+	ch <- prometheus.MustNewConstMetric(c.activityCalories, prometheus.GaugeValue, float64(summary.ActivityCalories))
+	ch <- prometheus.MustNewConstMetric(c.activeMinutes, prometheus.GaugeValue, float64(summary.SedentaryMinutes), "sedentary")
+	ch <- prometheus.MustNewConstMetric(c.activeMinutes, prometheus.GaugeValue, float64(summary.LightlyActiveMinutes), "lightly")
+	ch <- prometheus.MustNewConstMetric(c.activeMinutes, prometheus.GaugeValue, float64(summary.FairlyActiveMinutes), "fairly")
+	ch <- prometheus.MustNewConstMetric(c.activeMinutes, prometheus.GaugeValue, float64(summary.VeryActiveMinutes), "very")
+
+	goals := c.result.Goals
+	progress := []struct {
+		goal   string
+		target float64
+		value  float64
+	}{
+		{"steps", float64(goals.Steps), float64(summary.Steps)},
+		{"distance", goals.Distance * 1000, summary.TotalDistance() * 1000},
+		{"floors", float64(goals.Floors), float64(summary.Floors)},
+		{"calories_out", float64(goals.CaloriesOut), float64(summary.CaloriesOut)},
+		{"active_minutes", float64(goals.ActiveMinutes), float64(summary.FairlyActiveMinutes + summary.VeryActiveMinutes)},
+	}
+	for _, p := range progress {
+		ch <- prometheus.MustNewConstMetric(c.goal, prometheus.GaugeValue, p.target, p.goal)
+		if p.target > 0 {
+			ch <- prometheus.MustNewConstMetric(c.goalProgress, prometheus.GaugeValue, p.value/p.target, p.goal)
+		}
+	}
+}
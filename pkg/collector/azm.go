@@ -0,0 +1,99 @@
+package collector
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/mitch000001/fitbit-exporter/pkg/fitbit"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ActiveZoneMinutes is a prometheus.Collector exposing the most recently
+// fetched daily and intraday Active Zone Minutes.
+type ActiveZoneMinutes struct {
+	mutex    sync.Mutex
+	daily    *fitbit.ActiveZoneMinutesValue
+	intraday *fitbit.ActiveZoneMinutesValue
+
+	dailyDesc    *prometheus.Desc
+	intradayDesc *prometheus.Desc
+}
+
+func NewActiveZoneMinutes() *ActiveZoneMinutes {
+	return &ActiveZoneMinutes{
+		dailyDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "active_zone_minutes"),
+			"The Active Zone Minutes earned today by heart rate zone.",
+			[]string{"zone"}, nil,
+		),
+		intradayDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "intraday", "active_zone_minutes"),
+			"The Active Zone Minutes earned within the latest completed intraday interval by heart rate zone.",
+			[]string{"zone"}, nil,
+		),
+	}
+}
+
+// UpdateDaily replaces the daily Active Zone Minutes exposed by the collector.
+func (c *ActiveZoneMinutes) UpdateDaily(result fitbit.ActiveZoneMinutesResult) {
+	if len(result.ActiveZoneMinutes) == 0 {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.daily = &result.ActiveZoneMinutes[len(result.ActiveZoneMinutes)-1].Value
+}
+
+// UpdateIntraday replaces the intraday Active Zone Minutes exposed by the
+// collector with the latest completed interval at now, which has to be within
+// the timezone of the user. Fitbit omits intervals without Active Zone
+// Minutes, thus a missing interval resets the value.
+func (c *ActiveZoneMinutes) UpdateIntraday(result fitbit.ActiveZoneMinutesIntradayResult, detailLevel fitbit.DetailLevel, now time.Time) {
+	interval := detailLevel.Duration()
+	latestStart := intervalStart(now, interval).Add(-interval)
+	latest := fitbit.ActiveZoneMinutesValue{}
+	for _, intraday := range result.Intraday {
+		for _, minute := range intraday.Minutes {
+			start, err := time.ParseInLocation(fitbit.MinuteFormat, minute.Minute, now.Location())
+			if err != nil {
+				log.Printf("Error parsing Active Zone Minutes interval %q: %v", minute.Minute, err)
+				continue
+			}
+			if start.Equal(latestStart) {
+				latest = minute.Value
+			}
+		}
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.intraday = &latest
+}
+
+// intervalStart returns the start of the interval of the day containing t.
+func intervalStart(t time.Time, interval time.Duration) time.Time {
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return midnight.Add(t.Sub(midnight) / interval * interval)
+}
+
+func (c *ActiveZoneMinutes) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.dailyDesc
+	ch <- c.intradayDesc
+}
+
+func (c *ActiveZoneMinutes) Collect(ch chan<- prometheus.Metric) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.daily != nil {
+		collectActiveZoneMinutes(ch, c.dailyDesc, *c.daily)
+	}
+	if c.intraday != nil {
+		collectActiveZoneMinutes(ch, c.intradayDesc, *c.intraday)
+	}
+}
+
+func collectActiveZoneMinutes(ch chan<- prometheus.Metric, desc *prometheus.Desc, value fitbit.ActiveZoneMinutesValue) {
+	ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(value.FatBurnActiveZoneMinutes), "fat_burn")
+	ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(value.CardioActiveZoneMinutes), "cardio")
+	ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(value.PeakActiveZoneMinutes), "peak")
+}
@@ -0,0 +1,53 @@
+package collector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mitch000001/fitbit-exporter/pkg/fitbit"
+)
+
+func TestActiveZoneMinutesLatestInterval(t *testing.T) {
+	loc := time.FixedZone("UTC-5", -5*60*60)
+	result := fitbit.ActiveZoneMinutesIntradayResult{
+		Intraday: []fitbit.ActiveZoneMinutesIntraday{
+			{
+				DateTime: "2021-08-01",
+				Minutes: []fitbit.ActiveZoneMinutesIntradayValue{
+					{Minute: "2021-08-01T13:05:00", Value: fitbit.ActiveZoneMinutesValue{ActiveZoneMinutes: 2, CardioActiveZoneMinutes: 1}},
+					{Minute: "2021-08-01T13:10:00", Value: fitbit.ActiveZoneMinutesValue{ActiveZoneMinutes: 1, FatBurnActiveZoneMinutes: 1}},
+				},
+			},
+		},
+	}
+	tests := []struct {
+		name    string
+		now     time.Time
+		fatBurn float64
+		cardio  float64
+	}{
+		{name: "latest interval completed", now: time.Date(2021, 8, 1, 13, 15, 30, 0, loc), fatBurn: 1},
+		{name: "earlier interval", now: time.Date(2021, 8, 1, 13, 12, 0, 0, loc), cardio: 1},
+		{name: "latest interval missing", now: time.Date(2021, 8, 1, 13, 45, 0, 0, loc)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := NewActiveZoneMinutes()
+
+			c.UpdateIntraday(result, fitbit.DetailLevel5Min, test.now)
+
+			values := make(map[string]float64)
+			for _, m := range collect(t, c.intradayDesc, c) {
+				for _, label := range m.GetLabel() {
+					values[label.GetValue()] = m.GetGauge().GetValue()
+				}
+			}
+			if len(values) != 3 {
+				t.Fatalf("expected a value for every zone, got %v", values)
+			}
+			if values["fat_burn"] != test.fatBurn || values["cardio"] != test.cardio || values["peak"] != 0 {
+				t.Errorf("expected fat_burn=%v cardio=%v peak=0, got %v", test.fatBurn, test.cardio, values)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package collector
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/mitch000001/fitbit-exporter/pkg/fitbit"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Body is a prometheus.Collector exposing the latest weight and body fat logs.
+type Body struct {
+	mutex      sync.Mutex
+	weightLog  *fitbit.WeightLog
+	unitSystem fitbit.UnitSystem
+	bodyFatLog *fitbit.BodyFatLog
+
+	weight  *prometheus.Desc
+	bmi     *prometheus.Desc
+	bodyFat *prometheus.Desc
+}
+
+func NewBody() *Body {
+	return &Body{
+		weight: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "body", "weight_kilograms"),
+			"The latest logged body weight in kilograms.",
+			[]string{"source"}, nil,
+		),
+		bmi: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "body", "mass_index"),
+			"The body mass index of the latest weight log.",
+			[]string{"source"}, nil,
+		),
+		bodyFat: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "body", "fat_ratio"),
+			"The latest logged body fat as ratio between 0 and 1.",
+			[]string{"source"}, nil,
+		),
+	}
+}
+
+// UpdateWeight replaces the weight log exposed by the collector with the
+// latest log of the result. If the result contains no logs the previous one
+// is kept.
+func (c *Body) UpdateWeight(result fitbit.WeightLogResult) {
+	if len(result.Weight) == 0 {
+		return
+	}
+	latest := result.Weight[len(result.Weight)-1]
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.weightLog = &latest
+	c.unitSystem = result.UnitSystem
+}
+
+// UpdateBodyFat replaces the body fat log exposed by the collector with the
+// latest log of the result. If the result contains no logs the previous one
+// is kept.
+func (c *Body) UpdateBodyFat(result fitbit.BodyFatLogResult) {
+	if len(result.Fat) == 0 {
+		return
+	}
+	latest := result.Fat[len(result.Fat)-1]
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.bodyFatLog = &latest
+}
+
+func (c *Body) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.weight
+	ch <- c.bmi
+	ch <- c.bodyFat
+}
+
+func (c *Body) Collect(ch chan<- prometheus.Metric) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.weightLog != nil {
+		source := strings.ToLower(c.weightLog.Source)
+		ch <- prometheus.MustNewConstMetric(c.weight, prometheus.GaugeValue, c.unitSystem.Kilograms(c.weightLog.Weight), source)
+		ch <- prometheus.MustNewConstMetric(c.bmi, prometheus.GaugeValue, c.weightLog.BMI, source)
+	}
+	if c.bodyFatLog != nil {
+		source := strings.ToLower(c.bodyFatLog.Source)
+		ch <- prometheus.MustNewConstMetric(c.bodyFat, prometheus.GaugeValue, c.bodyFatLog.Fat/100, source)
+	}
+}
@@ -0,0 +1,75 @@
+package collector
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/mitch000001/fitbit-exporter/pkg/fitbit"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Devices is a prometheus.Collector exposing the most recently fetched
+// devices paired with the account.
+type Devices struct {
+	mutex    sync.Mutex
+	devices  []fitbit.Device
+	location *time.Location
+
+	info         *prometheus.Desc
+	batteryLevel *prometheus.Desc
+	lastSync     *prometheus.Desc
+}
+
+var deviceLabels = []string{"device_id", "device_version", "type"}
+
+func NewDevices() *Devices {
+	return &Devices{
+		info: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "device", "info"),
+			"Information about the paired device, always 1.",
+			append(deviceLabels, "mac", "battery"), nil,
+		),
+		batteryLevel: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "device", "battery_level_percent"),
+			"The battery level of the device in percent.",
+			deviceLabels, nil,
+		),
+		lastSync: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "device", "last_sync_timestamp_seconds"),
+			"The last time the device synced as unix timestamp.",
+			deviceLabels, nil,
+		),
+	}
+}
+
+// Update replaces the devices exposed by the collector. loc is the timezone of
+// the user the last sync times are given in.
+func (c *Devices) Update(devices []fitbit.Device, loc *time.Location) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.devices = devices
+	c.location = loc
+}
+
+func (c *Devices) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.info
+	ch <- c.batteryLevel
+	ch <- c.lastSync
+}
+
+func (c *Devices) Collect(ch chan<- prometheus.Metric) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, device := range c.devices {
+		labels := []string{device.ID, device.DeviceVersion, device.Type}
+		ch <- prometheus.MustNewConstMetric(c.info, prometheus.GaugeValue, 1, append(labels, device.Mac, device.Battery)...)
+		ch <- prometheus.MustNewConstMetric(c.batteryLevel, prometheus.GaugeValue, float64(device.BatteryLevel), labels...)
+		lastSync, err := fitbit.ParseDateTime(device.LastSyncTime, c.location)
+		if err != nil {
+			log.Printf("Error parsing last sync time %q of device %s: %v", device.LastSyncTime, device.ID, err)
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(c.lastSync, prometheus.GaugeValue, float64(lastSync.Unix()), labels...)
+	}
+}
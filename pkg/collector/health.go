@@ -0,0 +1,149 @@
+package collector
+
+import (
+	"log"
+	"sync"
+
+	"github.com/mitch000001/fitbit-exporter/pkg/fitbit"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Health is a prometheus.Collector exposing the most recently fetched
+// nightly health metrics, i.e. SpO2, heart rate variability, breathing rate,
+// skin temperature and the cardio fitness score.
+type Health struct {
+	mutex           sync.Mutex
+	spo2            *fitbit.SpO2Value
+	hrv             *fitbit.HeartRateVariabilityValue
+	breathingRate   *fitbit.BreathingRateValue
+	skinTemperature *fitbit.SkinTemperatureValue
+	cardioScore     *fitbit.CardioScoreValue
+
+	spo2Desc            *prometheus.Desc
+	hrvDesc             *prometheus.Desc
+	breathingRateDesc   *prometheus.Desc
+	skinTemperatureDesc *prometheus.Desc
+	vo2MaxDesc          *prometheus.Desc
+}
+
+func NewHealth() *Health {
+	return &Health{
+		spo2Desc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "spo2", "percent"),
+			"The blood oxygen saturation during the latest sleep in percent.",
+			[]string{"stat"}, nil,
+		),
+		hrvDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "hrv", "rmssd_milliseconds"),
+			"The root mean square of successive differences of the heart rate variability during the latest sleep in milliseconds.",
+			[]string{"phase"}, nil,
+		),
+		breathingRateDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "breathing_rate_per_minute"),
+			"The average breathing rate during the latest sleep in breaths per minute.",
+			nil, nil,
+		),
+		skinTemperatureDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "skin_temperature_variation_celsius"),
+			"The skin temperature during the latest sleep relative to the personal baseline in degrees Celsius.",
+			nil, nil,
+		),
+		vo2MaxDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "cardio_fitness", "vo2_max"),
+			"The cardio fitness score as VO2 max in ml/kg/min. A range is exposed as low and high bound.",
+			[]string{"bound"}, nil,
+		),
+	}
+}
+
+// UpdateSpO2 replaces the SpO2 exposed by the collector. Results without
+// data are ignored.
+func (c *Health) UpdateSpO2(result fitbit.SpO2Result) {
+	if result.DateTime == "" {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.spo2 = &result.Value
+}
+
+// UpdateHeartRateVariability replaces the heart rate variability exposed by
+// the collector. Results without data are ignored.
+func (c *Health) UpdateHeartRateVariability(result fitbit.HeartRateVariabilityResult) {
+	if len(result.HRV) == 0 {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.hrv = &result.HRV[len(result.HRV)-1].Value
+}
+
+// UpdateBreathingRate replaces the breathing rate exposed by the collector.
+// Results without data are ignored.
+func (c *Health) UpdateBreathingRate(result fitbit.BreathingRateResult) {
+	if len(result.BreathingRate) == 0 {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.breathingRate = &result.BreathingRate[len(result.BreathingRate)-1].Value
+}
+
+// UpdateSkinTemperature replaces the skin temperature exposed by the
+// collector. Results without data are ignored.
+func (c *Health) UpdateSkinTemperature(result fitbit.SkinTemperatureResult) {
+	if len(result.TempSkin) == 0 {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.skinTemperature = &result.TempSkin[len(result.TempSkin)-1].Value
+}
+
+// UpdateCardioFitness replaces the cardio fitness score exposed by the
+// collector. Results without data are ignored.
+func (c *Health) UpdateCardioFitness(result fitbit.CardioFitnessResult) {
+	if len(result.CardioScore) == 0 {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.cardioScore = &result.CardioScore[len(result.CardioScore)-1].Value
+}
+
+func (c *Health) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.spo2Desc
+	ch <- c.hrvDesc
+	ch <- c.breathingRateDesc
+	ch <- c.skinTemperatureDesc
+	ch <- c.vo2MaxDesc
+}
+
+func (c *Health) Collect(ch chan<- prometheus.Metric) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.spo2 != nil {
+		ch <- prometheus.MustNewConstMetric(c.spo2Desc, prometheus.GaugeValue, c.spo2.Avg, "avg")
+		ch <- prometheus.MustNewConstMetric(c.spo2Desc, prometheus.GaugeValue, c.spo2.Min, "min")
+		ch <- prometheus.MustNewConstMetric(c.spo2Desc, prometheus.GaugeValue, c.spo2.Max, "max")
+	}
+	if c.hrv != nil {
+		ch <- prometheus.MustNewConstMetric(c.hrvDesc, prometheus.GaugeValue, c.hrv.DailyRmssd, "daily")
+		ch <- prometheus.MustNewConstMetric(c.hrvDesc, prometheus.GaugeValue, c.hrv.DeepRmssd, "deep")
+	}
+	if c.breathingRate != nil {
+		ch <- prometheus.MustNewConstMetric(c.breathingRateDesc, prometheus.GaugeValue, c.breathingRate.BreathingRate)
+	}
+	if c.skinTemperature != nil {
+		ch <- prometheus.MustNewConstMetric(c.skinTemperatureDesc, prometheus.GaugeValue, c.skinTemperature.NightlyRelative)
+	}
+	if c.cardioScore != nil {
+		low, high, err := c.cardioScore.Range()
+		if err != nil {
+			log.Printf("Error getting cardio fitness score: %v", err)
+			return
+		}
+		ch <- prometheus.MustNewConstMetric(c.vo2MaxDesc, prometheus.GaugeValue, low, "low")
+		ch <- prometheus.MustNewConstMetric(c.vo2MaxDesc, prometheus.GaugeValue, high, "high")
+	}
+}
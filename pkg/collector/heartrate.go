@@ -0,0 +1,97 @@
+package collector
+
+import (
+	"sync"
+
+	"github.com/mitch000001/fitbit-exporter/pkg/fitbit"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "fitbit"
+
+// HeartRate is a prometheus.Collector exposing the latest intraday heart rate
+// sample and the most recently fetched daily heart rate summary.
+type HeartRate struct {
+	mutex  sync.Mutex
+	latest *fitbit.HeartActivityIntradayDatasetValue
+	daily  *fitbit.HeartRateSummaryValue
+
+	heartRate        *prometheus.Desc
+	restingHeartRate *prometheus.Desc
+	zoneMinutes      *prometheus.Desc
+	zoneCaloriesOut  *prometheus.Desc
+}
+
+func NewHeartRate() *HeartRate {
+	return &HeartRate{
+		heartRate: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "heart_rate_bpm"),
+			"The latest heart rate sample in beats per minute.",
+			nil, nil,
+		),
+		restingHeartRate: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "resting_heart_rate_bpm"),
+			"The daily resting heart rate in beats per minute.",
+			nil, nil,
+		),
+		zoneMinutes: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "heart_rate_zone_minutes"),
+			"The minutes spent within the heart rate zone today.",
+			[]string{"zone"}, nil,
+		),
+		zoneCaloriesOut: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "heart_rate_zone_calories_out"),
+			"The calories burned within the heart rate zone today.",
+			[]string{"zone"}, nil,
+		),
+	}
+}
+
+// Update replaces the latest heart rate sample exposed by the collector. The
+// previous sample is kept if the result contains none, e.g. as the time window
+// has just moved on to the next hour and the device did not sync since.
+func (c *HeartRate) Update(result fitbit.HeartRateResult) {
+	dataset := result.ActivitiesIntraDay.Dataset
+	if len(dataset) == 0 {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.latest = &dataset[len(dataset)-1]
+}
+
+// UpdateDaily replaces the resting heart rate and heart rate zones of the day
+// exposed by the collector.
+func (c *HeartRate) UpdateDaily(result fitbit.HeartRateSummaryResult) {
+	if len(result.Activities) == 0 {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.daily = &result.Activities[len(result.Activities)-1].Value
+}
+
+func (c *HeartRate) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.heartRate
+	ch <- c.restingHeartRate
+	ch <- c.zoneMinutes
+	ch <- c.zoneCaloriesOut
+}
+
+func (c *HeartRate) Collect(ch chan<- prometheus.Metric) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.latest != nil {
+		ch <- prometheus.MustNewConstMetric(c.heartRate, prometheus.GaugeValue, float64(c.latest.Value))
+	}
+	if c.daily == nil {
+		return
+	}
+	if c.daily.RestingHeartRate > 0 {
+		ch <- prometheus.MustNewConstMetric(c.restingHeartRate, prometheus.GaugeValue, float64(c.daily.RestingHeartRate))
+	}
+	for _, zone := range c.daily.HeartRateZones {
+		ch <- prometheus.MustNewConstMetric(c.zoneMinutes, prometheus.GaugeValue, float64(zone.Minutes), zone.Name)
+		ch <- prometheus.MustNewConstMetric(c.zoneCaloriesOut, prometheus.GaugeValue, zone.CaloriesOut, zone.Name)
+	}
+}
@@ -0,0 +1,80 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/mitch000001/fitbit-exporter/pkg/fitbit"
+)
+
+func TestHeartRateDailySummary(t *testing.T) {
+	c := NewHeartRate()
+
+	c.UpdateDaily(fitbit.HeartRateSummaryResult{
+		Activities: []fitbit.HeartRateSummary{
+			{
+				DateTime: "2021-08-01",
+				Value: fitbit.HeartRateSummaryValue{
+					HeartRateZones: []fitbit.HeartRateZone{
+						{Name: "Out of Range", Minutes: 1285, CaloriesOut: 1834.2349},
+						{Name: "Fat Burn", Minutes: 21, CaloriesOut: 155.5},
+					},
+					RestingHeartRate: 58,
+				},
+			},
+		},
+	})
+
+	resting := collect(t, c.restingHeartRate, c)
+	if len(resting) != 1 || resting[0].GetGauge().GetValue() != 58 {
+		t.Errorf("expected a resting heart rate of 58, got %v", resting)
+	}
+	minutes := make(map[string]float64)
+	for _, m := range collect(t, c.zoneMinutes, c) {
+		minutes[m.GetLabel()[0].GetValue()] = m.GetGauge().GetValue()
+	}
+	if len(minutes) != 2 || minutes["Out of Range"] != 1285 || minutes["Fat Burn"] != 21 {
+		t.Errorf("expected the minutes of the daily summary, got %v", minutes)
+	}
+	calories := make(map[string]float64)
+	for _, m := range collect(t, c.zoneCaloriesOut, c) {
+		calories[m.GetLabel()[0].GetValue()] = m.GetGauge().GetValue()
+	}
+	if len(calories) != 2 || calories["Out of Range"] != 1834.2349 || calories["Fat Burn"] != 155.5 {
+		t.Errorf("expected the calories of the daily summary, got %v", calories)
+	}
+
+	c.UpdateDaily(fitbit.HeartRateSummaryResult{
+		Activities: []fitbit.HeartRateSummary{{DateTime: "2021-08-02"}},
+	})
+
+	if resting := collect(t, c.restingHeartRate, c); len(resting) != 0 {
+		t.Errorf("expected no resting heart rate until it is determined, got %v", resting)
+	}
+}
+
+func TestHeartRateKeepsLatestSample(t *testing.T) {
+	c := NewHeartRate()
+	result := func(samples ...fitbit.HeartActivityIntradayDatasetValue) fitbit.HeartRateResult {
+		return fitbit.HeartRateResult{
+			ActivitiesIntraDay: fitbit.HeartActivityIntraday{Dataset: samples},
+		}
+	}
+
+	c.Update(result(
+		fitbit.HeartActivityIntradayDatasetValue{Time: "13:58:41", Value: 91},
+		fitbit.HeartActivityIntradayDatasetValue{Time: "13:59:56", Value: 94},
+	))
+	c.Update(result())
+
+	heartRates := collect(t, c.heartRate, c)
+	if len(heartRates) != 1 || heartRates[0].GetGauge().GetValue() != 94 {
+		t.Errorf("expected the latest sample of 94 to be kept, got %v", heartRates)
+	}
+
+	c.Update(result(fitbit.HeartActivityIntradayDatasetValue{Time: "14:00:03", Value: 88}))
+
+	heartRates = collect(t, c.heartRate, c)
+	if len(heartRates) != 1 || heartRates[0].GetGauge().GetValue() != 88 {
+		t.Errorf("expected the latest sample of 88, got %v", heartRates)
+	}
+}
@@ -0,0 +1,136 @@
+package collector
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/mitch000001/fitbit-exporter/pkg/fitbit"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Intraday is a prometheus.Collector exposing the intraday time series of
+// each activity resource. As only a single sample per series can be exposed
+// per scrape, every resource is exposed as a counter of all completed
+// intervals, whose rate yields the intraday curve, and as gauge of the latest
+// completed interval with the timestamp of that interval.
+type Intraday struct {
+	mutex  sync.Mutex
+	series map[fitbit.IntradayResource]*intradaySeries
+
+	descs map[fitbit.IntradayResource]intradayDesc
+}
+
+type intradayDesc struct {
+	latest *prometheus.Desc
+	total  *prometheus.Desc
+	scale  float64
+}
+
+// intradaySeries accumulates the completed intervals of a resource. Samples
+// are synced with a delay and may still grow after they have been counted, so
+// the counted values of the fetched intervals are kept to only add the growth.
+type intradaySeries struct {
+	total    float64
+	counted  map[time.Time]float64
+	latest   float64
+	latestAt time.Time
+}
+
+func newIntradayDesc(name, unit, help string, scale float64) intradayDesc {
+	fqName := prometheus.BuildFQName(namespace, "intraday", name)
+	if unit != "" {
+		fqName += "_" + unit
+	}
+	return intradayDesc{
+		latest: prometheus.NewDesc(
+			fqName,
+			"The "+help+" within the latest completed intraday interval.",
+			nil, nil,
+		),
+		total: prometheus.NewDesc(
+			fqName+"_total",
+			"The "+help+" within all completed intraday intervals since the exporter started.",
+			nil, nil,
+		),
+		scale: scale,
+	}
+}
+
+func NewIntraday() *Intraday {
+	return &Intraday{
+		series: make(map[fitbit.IntradayResource]*intradaySeries),
+		descs: map[fitbit.IntradayResource]intradayDesc{
+			fitbit.IntradaySteps:     newIntradayDesc("steps", "", "steps taken", 1),
+			fitbit.IntradayCalories:  newIntradayDesc("calories", "", "calories burned", 1),
+			fitbit.IntradayDistance:  newIntradayDesc("distance", "meters", "distance covered in meters", 1000),
+			fitbit.IntradayFloors:    newIntradayDesc("floors", "", "floors climbed", 1),
+			fitbit.IntradayElevation: newIntradayDesc("elevation", "meters", "elevation climbed in meters", 1),
+		},
+	}
+}
+
+// Update adds the intervals of the result's time series which are completed
+// at now. The time series has to be fetched for the day of now, which has to
+// be within the timezone of the user.
+func (c *Intraday) Update(result fitbit.IntradayResult, now time.Time) {
+	interval := result.Intraday.Interval()
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	series, ok := c.series[result.Resource]
+	if !ok {
+		series = &intradaySeries{}
+		c.series[result.Resource] = series
+	}
+	// Only the intervals within the fetched time window are kept, as earlier
+	// intervals are not fetched again.
+	counted := make(map[time.Time]float64, len(result.Intraday.Dataset))
+	for _, sample := range result.Intraday.Dataset {
+		start, err := fitbit.ParseDatasetTime(now, sample.Time)
+		if err != nil {
+			log.Printf("Error parsing intraday %s sample time %q: %v", result.Resource, sample.Time, err)
+			continue
+		}
+		if start.Add(interval).After(now) {
+			continue
+		}
+		value := sample.Value
+		if previous, ok := series.counted[start]; ok && previous >= value {
+			value = previous
+		} else {
+			series.total += value - previous
+		}
+		counted[start] = value
+		if !start.Before(series.latestAt) {
+			series.latest = value
+			series.latestAt = start
+		}
+	}
+	series.counted = counted
+}
+
+func (c *Intraday) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range c.descs {
+		ch <- desc.latest
+		ch <- desc.total
+	}
+}
+
+func (c *Intraday) Collect(ch chan<- prometheus.Metric) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for resource, series := range c.series {
+		desc, ok := c.descs[resource]
+		if !ok {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(desc.total, prometheus.CounterValue, series.total*desc.scale)
+		if series.latestAt.IsZero() {
+			continue
+		}
+		ch <- prometheus.NewMetricWithTimestamp(
+			series.latestAt,
+			prometheus.MustNewConstMetric(desc.latest, prometheus.GaugeValue, series.latest*desc.scale),
+		)
+	}
+}
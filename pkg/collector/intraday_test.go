@@ -0,0 +1,95 @@
+package collector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mitch000001/fitbit-exporter/pkg/fitbit"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// collect returns the metrics of the collector with the description.
+func collect(t *testing.T, desc *prometheus.Desc, c prometheus.Collector) []*dto.Metric {
+	t.Helper()
+	ch := make(chan prometheus.Metric)
+	go func() {
+		c.Collect(ch)
+		close(ch)
+	}()
+	var metrics []*dto.Metric
+	for metric := range ch {
+		if metric.Desc() != desc {
+			continue
+		}
+		var m dto.Metric
+		if err := metric.Write(&m); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		metrics = append(metrics, &m)
+	}
+	return metrics
+}
+
+func stepsResult(samples ...fitbit.IntradayDatasetValue) fitbit.IntradayResult {
+	return fitbit.IntradayResult{
+		Resource: fitbit.IntradaySteps,
+		Intraday: fitbit.IntradayDataset{
+			Dataset:         samples,
+			DatasetInterval: 1,
+			DatasetType:     "minute",
+		},
+	}
+}
+
+func TestIntradayCountsCompletedIntervals(t *testing.T) {
+	loc := time.FixedZone("UTC+2", 2*60*60)
+	day := time.Date(2021, 8, 1, 0, 0, 0, 0, loc)
+	c := NewIntraday()
+	descs := c.descs[fitbit.IntradaySteps]
+
+	expect := func(total, latest float64, latestAt time.Time) {
+		t.Helper()
+		totals := collect(t, descs.total, c)
+		if len(totals) != 1 {
+			t.Fatalf("expected a single total, got %d", len(totals))
+		}
+		if value := totals[0].GetCounter().GetValue(); value != total {
+			t.Errorf("expected total of %v steps, got %v", total, value)
+		}
+		latests := collect(t, descs.latest, c)
+		if len(latests) != 1 {
+			t.Fatalf("expected a single latest interval, got %d", len(latests))
+		}
+		m := latests[0]
+		if value := m.GetGauge().GetValue(); value != latest {
+			t.Errorf("expected latest interval with %v steps, got %v", latest, value)
+		}
+		if timestamp := m.GetTimestampMs(); timestamp != latestAt.UnixNano()/int64(time.Millisecond) {
+			t.Errorf("expected latest interval at %v, got %v", latestAt, time.Unix(0, timestamp*int64(time.Millisecond)))
+		}
+	}
+
+	// The interval of 13:10 is not yet completed.
+	c.Update(stepsResult(
+		fitbit.IntradayDatasetValue{Time: "13:08:00", Value: 5},
+		fitbit.IntradayDatasetValue{Time: "13:09:00", Value: 7},
+		fitbit.IntradayDatasetValue{Time: "13:10:00", Value: 3},
+	), day.Add(13*time.Hour+10*time.Minute+30*time.Second))
+	expect(12, 7, day.Add(13*time.Hour+9*time.Minute))
+
+	// Intervals synced late grow after they have been counted.
+	c.Update(stepsResult(
+		fitbit.IntradayDatasetValue{Time: "13:08:00", Value: 5},
+		fitbit.IntradayDatasetValue{Time: "13:09:00", Value: 9},
+		fitbit.IntradayDatasetValue{Time: "13:10:00", Value: 4},
+		fitbit.IntradayDatasetValue{Time: "13:11:00", Value: 0},
+	), day.Add(13*time.Hour+25*time.Minute))
+	expect(18, 0, day.Add(13*time.Hour+11*time.Minute))
+
+	// Intervals outside of the fetched window are kept within the total.
+	c.Update(stepsResult(
+		fitbit.IntradayDatasetValue{Time: "14:00:00", Value: 20},
+	), day.Add(14*time.Hour+5*time.Minute))
+	expect(38, 20, day.Add(14*time.Hour))
+}
@@ -0,0 +1,74 @@
+package collector
+
+import (
+	"sync"
+
+	"github.com/mitch000001/fitbit-exporter/pkg/fitbit"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Leaderboard is a prometheus.Collector exposing the most recently fetched
+// friends leaderboard. If an allowlist is given only friends whose ID or
+// name is on it are exposed.
+type Leaderboard struct {
+	mutex     sync.Mutex
+	result    *fitbit.LeaderboardResult
+	allowlist map[string]bool
+
+	steps *prometheus.Desc
+	rank  *prometheus.Desc
+}
+
+func NewLeaderboard(allowlist []string) *Leaderboard {
+	var allowed map[string]bool
+	if len(allowlist) > 0 {
+		allowed = make(map[string]bool, len(allowlist))
+		for _, friend := range allowlist {
+			allowed[friend] = true
+		}
+	}
+	return &Leaderboard{
+		allowlist: allowed,
+		steps: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "leaderboard", "steps"),
+			"The steps of the friend within the last 7 days.",
+			[]string{"friend_id", "friend"}, nil,
+		),
+		rank: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "leaderboard", "rank"),
+			"The rank of the friend on the 7 day step leaderboard.",
+			[]string{"friend_id", "friend"}, nil,
+		),
+	}
+}
+
+// Update replaces the leaderboard exposed by the collector.
+func (c *Leaderboard) Update(result fitbit.LeaderboardResult) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.result = &result
+}
+
+func (c *Leaderboard) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.steps
+	ch <- c.rank
+}
+
+func (c *Leaderboard) Collect(ch chan<- prometheus.Metric) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.result == nil {
+		return
+	}
+	names := c.result.Names()
+	for _, entry := range c.result.Data {
+		name := names[entry.ID]
+		if c.allowlist != nil && !c.allowlist[entry.ID] && !c.allowlist[name] {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(c.steps, prometheus.GaugeValue, float64(entry.Attributes.StepSummary), entry.ID, name)
+		if entry.Attributes.StepRank > 0 {
+			ch <- prometheus.MustNewConstMetric(c.rank, prometheus.GaugeValue, float64(entry.Attributes.StepRank), entry.ID, name)
+		}
+	}
+}
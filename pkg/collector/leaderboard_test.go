@@ -0,0 +1,65 @@
+package collector
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/mitch000001/fitbit-exporter/pkg/fitbit"
+)
+
+func TestLeaderboardAllowlist(t *testing.T) {
+	person := func(id, name string) fitbit.LeaderboardPerson {
+		return fitbit.LeaderboardPerson{ID: id, Attributes: fitbit.LeaderboardPersonAttributes{Name: name}}
+	}
+	result := fitbit.LeaderboardResult{
+		Data: []fitbit.LeaderboardEntry{
+			{ID: "ABC123", Type: "ranked-user", Attributes: fitbit.LeaderboardEntryAttributes{StepRank: 1, StepSummary: 75312}},
+			{ID: "DEF456", Type: "ranked-user", Attributes: fitbit.LeaderboardEntryAttributes{StepRank: 2, StepSummary: 51234}},
+			{ID: "GHI789", Type: "inactive-user"},
+		},
+		Included: []fitbit.LeaderboardPerson{
+			person("ABC123", "Jane D."),
+			person("DEF456", "John D."),
+			person("GHI789", "Max M."),
+		},
+	}
+	tests := []struct {
+		name      string
+		allowlist []string
+		friends   []string
+	}{
+		{name: "no allowlist", friends: []string{"ABC123", "DEF456", "GHI789"}},
+		{name: "allowed by ID", allowlist: []string{"DEF456"}, friends: []string{"DEF456"}},
+		{name: "allowed by name", allowlist: []string{"Jane D.", "Max M."}, friends: []string{"ABC123", "GHI789"}},
+		{name: "unknown friend", allowlist: []string{"XYZ000"}},
+		{name: "partial name", allowlist: []string{"Jane"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := NewLeaderboard(test.allowlist)
+
+			c.Update(result)
+
+			var friends []string
+			for _, m := range collect(t, c.steps, c) {
+				for _, label := range m.GetLabel() {
+					if label.GetName() == "friend_id" {
+						friends = append(friends, label.GetValue())
+					}
+				}
+			}
+			sort.Strings(friends)
+			if !reflect.DeepEqual(friends, test.friends) {
+				t.Errorf("expected steps of friends %v, got %v", test.friends, friends)
+			}
+			for _, m := range collect(t, c.rank, c) {
+				for _, label := range m.GetLabel() {
+					if label.GetName() == "friend_id" && label.GetValue() == "GHI789" {
+						t.Errorf("expected no rank of inactive friend")
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package collector
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/mitch000001/fitbit-exporter/pkg/fitbit"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Lifetime is a prometheus.Collector exposing the most recently fetched
+// lifetime statistics and best days.
+type Lifetime struct {
+	mutex    sync.Mutex
+	result   *fitbit.LifetimeStatsResult
+	location *time.Location
+
+	distance     *prometheus.Desc
+	steps        *prometheus.Desc
+	floors       *prometheus.Desc
+	bestDistance *prometheus.Desc
+	bestSteps    *prometheus.Desc
+	bestFloors   *prometheus.Desc
+	bestDate     *prometheus.Desc
+}
+
+func NewLifetime() *Lifetime {
+	return &Lifetime{
+		distance: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "lifetime", "distance_meters"),
+			"The lifetime distance covered in meters.",
+			[]string{"source"}, nil,
+		),
+		steps: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "lifetime", "steps"),
+			"The lifetime steps taken.",
+			[]string{"source"}, nil,
+		),
+		floors: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "lifetime", "floors"),
+			"The lifetime floors climbed.",
+			[]string{"source"}, nil,
+		),
+		bestDistance: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "best_day", "distance_meters"),
+			"The distance covered on the best day in meters.",
+			[]string{"source"}, nil,
+		),
+		bestSteps: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "best_day", "steps"),
+			"The steps taken on the best day.",
+			[]string{"source"}, nil,
+		),
+		bestFloors: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "best_day", "floors"),
+			"The floors climbed on the best day.",
+			[]string{"source"}, nil,
+		),
+		bestDate: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "best_day", "timestamp_seconds"),
+			"The date of the best day as unix timestamp.",
+			[]string{"source", "stat"}, nil,
+		),
+	}
+}
+
+// Update replaces the lifetime statistics exposed by the collector. loc is the
+// timezone of the user the dates of the best days are given in.
+func (c *Lifetime) Update(result fitbit.LifetimeStatsResult, loc *time.Location) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.result = &result
+	c.location = loc
+}
+
+func (c *Lifetime) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.distance
+	ch <- c.steps
+	ch <- c.floors
+	ch <- c.bestDistance
+	ch <- c.bestSteps
+	ch <- c.bestFloors
+	ch <- c.bestDate
+}
+
+func (c *Lifetime) Collect(ch chan<- prometheus.Metric) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.result == nil {
+		return
+	}
+	c.collectLifetime(ch, "total", c.result.Lifetime.Total)
+	c.collectLifetime(ch, "tracker", c.result.Lifetime.Tracker)
+	c.collectBest(ch, "total", c.result.Best.Total)
+	c.collectBest(ch, "tracker", c.result.Best.Tracker)
+}
+
+func (c *Lifetime) collectLifetime(ch chan<- prometheus.Metric, source string, stats fitbit.LifetimeStats) {
+	ch <- prometheus.MustNewConstMetric(c.distance, prometheus.GaugeValue, stats.Distance*1000, source)
+	ch <- prometheus.MustNewConstMetric(c.steps, prometheus.GaugeValue, stats.Steps, source)
+	ch <- prometheus.MustNewConstMetric(c.floors, prometheus.GaugeValue, stats.Floors, source)
+}
+
+func (c *Lifetime) collectBest(ch chan<- prometheus.Metric, source string, stats fitbit.BestStats) {
+	ch <- prometheus.MustNewConstMetric(c.bestDistance, prometheus.GaugeValue, stats.Distance.Value*1000, source)
+	ch <- prometheus.MustNewConstMetric(c.bestSteps, prometheus.GaugeValue, stats.Steps.Value, source)
+	ch <- prometheus.MustNewConstMetric(c.bestFloors, prometheus.GaugeValue, stats.Floors.Value, source)
+	for stat, best := range map[string]fitbit.BestStat{
+		"distance": stats.Distance,
+		"steps":    stats.Steps,
+		"floors":   stats.Floors,
+	} {
+		if best.Date == "" {
+			continue
+		}
+		date, err := time.ParseInLocation(fitbit.DateFormat, best.Date, c.location)
+		if err != nil {
+			log.Printf("Error parsing date %q of best %s: %v", best.Date, stat, err)
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(c.bestDate, prometheus.GaugeValue, float64(date.Unix()), source, stat)
+	}
+}
@@ -0,0 +1,136 @@
+package collector
+
+import (
+	"sync"
+
+	"github.com/mitch000001/fitbit-exporter/pkg/fitbit"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Nutrition is a prometheus.Collector exposing the most recently fetched
+// food and water logs.
+type Nutrition struct {
+	mutex     sync.Mutex
+	foodLog   *fitbit.FoodLogResult
+	waterLog  *fitbit.WaterLogResult
+	waterGoal *fitbit.WaterGoal
+
+	caloriesIn     *prometheus.Desc
+	caloriesGoal   *prometheus.Desc
+	nutrients      *prometheus.Desc
+	sodium         *prometheus.Desc
+	mealCaloriesIn *prometheus.Desc
+	mealEntries    *prometheus.Desc
+	water          *prometheus.Desc
+	waterGoalDesc  *prometheus.Desc
+}
+
+func NewNutrition() *Nutrition {
+	return &Nutrition{
+		caloriesIn: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "nutrition", "calories_in"),
+			"The calories consumed today.",
+			nil, nil,
+		),
+		caloriesGoal: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "nutrition", "calories_goal"),
+			"The daily goal of calories to consume.",
+			nil, nil,
+		),
+		nutrients: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "nutrition", "grams"),
+			"The nutrients consumed today in grams.",
+			[]string{"nutrient"}, nil,
+		),
+		sodium: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "nutrition", "sodium_milligrams"),
+			"The sodium consumed today in milligrams.",
+			nil, nil,
+		),
+		mealCaloriesIn: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "nutrition", "meal_calories_in"),
+			"The calories consumed today by meal type.",
+			[]string{"meal"}, nil,
+		),
+		mealEntries: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "nutrition", "meal_entries"),
+			"The number of food log entries today by meal type.",
+			[]string{"meal"}, nil,
+		),
+		water: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "water", "consumed_milliliters"),
+			"The water consumed today in milliliters.",
+			nil, nil,
+		),
+		waterGoalDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "water", "goal_milliliters"),
+			"The daily goal of water to consume in milliliters.",
+			nil, nil,
+		),
+	}
+}
+
+// UpdateFoodLog replaces the food log exposed by the collector.
+func (c *Nutrition) UpdateFoodLog(result fitbit.FoodLogResult) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.foodLog = &result
+}
+
+// UpdateWaterLog replaces the water log exposed by the collector.
+func (c *Nutrition) UpdateWaterLog(result fitbit.WaterLogResult) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.waterLog = &result
+}
+
+// UpdateWaterGoal replaces the water goal exposed by the collector.
+func (c *Nutrition) UpdateWaterGoal(goal fitbit.WaterGoal) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.waterGoal = &goal
+}
+
+func (c *Nutrition) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.caloriesIn
+	ch <- c.caloriesGoal
+	ch <- c.nutrients
+	ch <- c.sodium
+	ch <- c.mealCaloriesIn
+	ch <- c.mealEntries
+	ch <- c.water
+	ch <- c.waterGoalDesc
+}
+
+func (c *Nutrition) Collect(ch chan<- prometheus.Metric) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.foodLog != nil {
+		summary := c.foodLog.Summary
+		ch <- prometheus.MustNewConstMetric(c.caloriesIn, prometheus.GaugeValue, summary.Calories)
+		ch <- prometheus.MustNewConstMetric(c.caloriesGoal, prometheus.GaugeValue, c.foodLog.Goals.Calories)
+		ch <- prometheus.MustNewConstMetric(c.nutrients, prometheus.GaugeValue, summary.Carbs, "carbs")
+		ch <- prometheus.MustNewConstMetric(c.nutrients, prometheus.GaugeValue, summary.Fat, "fat")
+		ch <- prometheus.MustNewConstMetric(c.nutrients, prometheus.GaugeValue, summary.Fiber, "fiber")
+		ch <- prometheus.MustNewConstMetric(c.nutrients, prometheus.GaugeValue, summary.Protein, "protein")
+		ch <- prometheus.MustNewConstMetric(c.sodium, prometheus.GaugeValue, summary.Sodium)
+
+		mealCalories := make(map[string]float64)
+		mealEntries := make(map[string]int)
+		for _, food := range c.foodLog.Foods {
+			meal := food.LoggedFood.MealTypeID.String()
+			mealCalories[meal] += food.NutritionalValues.Calories
+			mealEntries[meal]++
+		}
+		for meal, calories := range mealCalories {
+			ch <- prometheus.MustNewConstMetric(c.mealCaloriesIn, prometheus.GaugeValue, calories, meal)
+			ch <- prometheus.MustNewConstMetric(c.mealEntries, prometheus.GaugeValue, float64(mealEntries[meal]), meal)
+		}
+	}
+	if c.waterLog != nil {
+		ch <- prometheus.MustNewConstMetric(c.water, prometheus.GaugeValue, c.waterLog.Summary.Water)
+	}
+	if c.waterGoal != nil {
+		ch <- prometheus.MustNewConstMetric(c.waterGoalDesc, prometheus.GaugeValue, c.waterGoal.Goal)
+	}
+}
@@ -0,0 +1,75 @@
+package collector
+
+import (
+	"sync"
+
+	"github.com/mitch000001/fitbit-exporter/pkg/fitbit"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Profile is a prometheus.Collector exposing the most recently fetched
+// profile of every user. All metrics are labelled by the user ID.
+type Profile struct {
+	mutex    sync.Mutex
+	profiles map[string]fitbit.Profile
+
+	info         *prometheus.Desc
+	age          *prometheus.Desc
+	height       *prometheus.Desc
+	strideLength *prometheus.Desc
+}
+
+func NewProfile() *Profile {
+	return &Profile{
+		profiles: make(map[string]fitbit.Profile),
+		info: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "user", "info"),
+			"Information about the user, always 1.",
+			[]string{"user_id", "display_name", "timezone", "locale", "unit_system"}, nil,
+		),
+		age: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "user", "age_years"),
+			"The age of the user in years.",
+			[]string{"user_id"}, nil,
+		),
+		height: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "user", "height_meters"),
+			"The height of the user in meters.",
+			[]string{"user_id"}, nil,
+		),
+		strideLength: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "user", "stride_length_meters"),
+			"The stride length of the user by type in meters.",
+			[]string{"user_id", "type"}, nil,
+		),
+	}
+}
+
+// Update replaces the profile of the user exposed by the collector.
+func (c *Profile) Update(profile fitbit.Profile) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.profiles[profile.EncodedID] = profile
+}
+
+func (c *Profile) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.info
+	ch <- c.age
+	ch <- c.height
+	ch <- c.strideLength
+}
+
+func (c *Profile) Collect(ch chan<- prometheus.Metric) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for userID, profile := range c.profiles {
+		ch <- prometheus.MustNewConstMetric(
+			c.info, prometheus.GaugeValue, 1,
+			userID, profile.DisplayName, profile.Timezone, profile.Locale, string(profile.UnitSystem()),
+		)
+		ch <- prometheus.MustNewConstMetric(c.age, prometheus.GaugeValue, float64(profile.Age), userID)
+		ch <- prometheus.MustNewConstMetric(c.height, prometheus.GaugeValue, profile.Height/100, userID)
+		ch <- prometheus.MustNewConstMetric(c.strideLength, prometheus.GaugeValue, profile.StrideLengthWalking/100, userID, "walking")
+		ch <- prometheus.MustNewConstMetric(c.strideLength, prometheus.GaugeValue, profile.StrideLengthRunning/100, userID, "running")
+	}
+}
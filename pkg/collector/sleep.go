@@ -0,0 +1,113 @@
+package collector
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/mitch000001/fitbit-exporter/pkg/fitbit"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Sleep is a prometheus.Collector exposing the main sleep of the most
+// recently fetched sleep result.
+type Sleep struct {
+	mutex     sync.Mutex
+	mainSleep *fitbit.SleepLog
+	startTime time.Time
+	endTime   time.Time
+
+	duration   *prometheus.Desc
+	efficiency *prometheus.Desc
+	minutes    *prometheus.Desc
+	timeInBed  *prometheus.Desc
+	start      *prometheus.Desc
+	end        *prometheus.Desc
+}
+
+func NewSleep() *Sleep {
+	return &Sleep{
+		duration: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "sleep", "duration_seconds"),
+			"The time asleep during the main sleep in seconds.",
+			nil, nil,
+		),
+		efficiency: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "sleep", "efficiency_ratio"),
+			"The sleep efficiency of the main sleep as a ratio between 0 and 1.",
+			nil, nil,
+		),
+		minutes: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "sleep", "minutes"),
+			"The minutes spent in each sleep stage during the main sleep.",
+			[]string{"stage"}, nil,
+		),
+		timeInBed: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "sleep", "time_in_bed_seconds"),
+			"The time in bed during the main sleep in seconds.",
+			nil, nil,
+		),
+		start: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "sleep", "start_timestamp_seconds"),
+			"The start of the main sleep as unix timestamp.",
+			nil, nil,
+		),
+		end: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "sleep", "end_timestamp_seconds"),
+			"The end of the main sleep as unix timestamp.",
+			nil, nil,
+		),
+	}
+}
+
+// Update replaces the main sleep exposed by the collector. If the result
+// contains no main sleep the previous one is kept. loc is the timezone of the
+// user the sleep times are given in.
+func (c *Sleep) Update(result fitbit.SleepResult, loc *time.Location) {
+	mainSleep, ok := result.MainSleep()
+	if !ok {
+		return
+	}
+	startTime, err := fitbit.ParseDateTime(mainSleep.StartTime, loc)
+	if err != nil {
+		log.Printf("Error parsing sleep start time %q: %v", mainSleep.StartTime, err)
+	}
+	endTime, err := fitbit.ParseDateTime(mainSleep.EndTime, loc)
+	if err != nil {
+		log.Printf("Error parsing sleep end time %q: %v", mainSleep.EndTime, err)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.mainSleep = &mainSleep
+	c.startTime = startTime
+	c.endTime = endTime
+}
+
+func (c *Sleep) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.duration
+	ch <- c.efficiency
+	ch <- c.minutes
+	ch <- c.timeInBed
+	ch <- c.start
+	ch <- c.end
+}
+
+func (c *Sleep) Collect(ch chan<- prometheus.Metric) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.mainSleep == nil {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.duration, prometheus.GaugeValue, float64(c.mainSleep.MinutesAsleep*60))
+	ch <- prometheus.MustNewConstMetric(c.efficiency, prometheus.GaugeValue, float64(c.mainSleep.Efficiency)/100)
+	ch <- prometheus.MustNewConstMetric(c.timeInBed, prometheus.GaugeValue, float64(c.mainSleep.TimeInBed*60))
+	for stage, summary := range c.mainSleep.Levels.Summary {
+		ch <- prometheus.MustNewConstMetric(c.minutes, prometheus.GaugeValue, float64(summary.Minutes), stage)
+	}
+	if !c.startTime.IsZero() {
+		ch <- prometheus.MustNewConstMetric(c.start, prometheus.GaugeValue, float64(c.startTime.Unix()))
+	}
+	if !c.endTime.IsZero() {
+		ch <- prometheus.MustNewConstMetric(c.end, prometheus.GaugeValue, float64(c.endTime.Unix()))
+	}
+}
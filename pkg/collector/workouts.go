@@ -0,0 +1,112 @@
+package collector
+
+import (
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mitch000001/fitbit-exporter/pkg/fitbit"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Workouts is a prometheus.Collector exposing the most recently fetched
+// activity logs. Only the logs passed to Update are exposed, so the caller
+// bounds the cardinality by the number of logs it fetches.
+type Workouts struct {
+	mutex      sync.Mutex
+	activities []fitbit.ActivityLog
+
+	duration         *prometheus.Desc
+	calories         *prometheus.Desc
+	averageHeartRate *prometheus.Desc
+	steps            *prometheus.Desc
+	distance         *prometheus.Desc
+	zoneMinutes      *prometheus.Desc
+	start            *prometheus.Desc
+}
+
+var workoutLabels = []string{"log_id", "activity_name", "log_type"}
+
+func NewWorkouts() *Workouts {
+	return &Workouts{
+		duration: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "workout", "duration_seconds"),
+			"The active duration of the workout in seconds.",
+			workoutLabels, nil,
+		),
+		calories: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "workout", "calories"),
+			"The calories burned during the workout.",
+			workoutLabels, nil,
+		),
+		averageHeartRate: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "workout", "average_heart_rate_bpm"),
+			"The average heart rate during the workout in beats per minute.",
+			workoutLabels, nil,
+		),
+		steps: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "workout", "steps"),
+			"The steps taken during the workout.",
+			workoutLabels, nil,
+		),
+		distance: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "workout", "distance_meters"),
+			"The distance covered during the workout in meters.",
+			workoutLabels, nil,
+		),
+		zoneMinutes: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "workout", "heart_rate_zone_minutes"),
+			"The minutes spent within the heart rate zone during the workout.",
+			append(workoutLabels, "zone"), nil,
+		),
+		start: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "workout", "start_timestamp_seconds"),
+			"The start of the workout as unix timestamp.",
+			workoutLabels, nil,
+		),
+	}
+}
+
+// Update replaces the activity logs exposed by the collector.
+func (c *Workouts) Update(activities []fitbit.ActivityLog) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.activities = activities
+}
+
+func (c *Workouts) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.duration
+	ch <- c.calories
+	ch <- c.averageHeartRate
+	ch <- c.steps
+	ch <- c.distance
+	ch <- c.zoneMinutes
+	ch <- c.start
+}
+
+func (c *Workouts) Collect(ch chan<- prometheus.Metric) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, activity := range c.activities {
+		labels := []string{strconv.FormatInt(activity.LogID, 10), activity.ActivityName, activity.LogType}
+		ch <- prometheus.MustNewConstMetric(c.duration, prometheus.GaugeValue, float64(activity.ActiveDuration)/1000, labels...)
+		ch <- prometheus.MustNewConstMetric(c.calories, prometheus.GaugeValue, float64(activity.Calories), labels...)
+		if activity.AverageHeartRate > 0 {
+			ch <- prometheus.MustNewConstMetric(c.averageHeartRate, prometheus.GaugeValue, float64(activity.AverageHeartRate), labels...)
+		}
+		ch <- prometheus.MustNewConstMetric(c.steps, prometheus.GaugeValue, float64(activity.Steps), labels...)
+		if activity.DistanceUnit != "" {
+			ch <- prometheus.MustNewConstMetric(c.distance, prometheus.GaugeValue, activity.DistanceMeters(), labels...)
+		}
+		for _, zone := range activity.HeartRateZones {
+			ch <- prometheus.MustNewConstMetric(c.zoneMinutes, prometheus.GaugeValue, float64(zone.Minutes), append(labels, zone.Name)...)
+		}
+		start, err := time.Parse(fitbit.ActivityLogStartTimeFormat, activity.StartTime)
+		if err != nil {
+			log.Printf("Error parsing start time %q of workout %d: %v", activity.StartTime, activity.LogID, err)
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(c.start, prometheus.GaugeValue, float64(start.Unix()), labels...)
+	}
+}
@@ -0,0 +1,130 @@
+package fitbit
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ActivitySummaryByDate returns the daily activity summary of the given date.
+func (c *Client) ActivitySummaryByDate(ctx context.Context, date time.Time) (*ActivitySummaryResult, error) {
+	path := fmt.Sprintf("/1/user/-/activities/date/%s.json", formatDate(date))
+	var result ActivitySummaryResult
+	if err := c.get(ctx, path, nil, &result); err != nil {
+		return nil, fmt.Errorf("error getting activity summary: %w", err)
+	}
+	return &result, nil
+}
+
+// Sample:
+//
+// {
+//     "activities": [],
+//     "goals": {
+//         "activeMinutes": 30,
+//         "caloriesOut": 2826,
+//         "distance": 8.05,
+//         "floors": 10,
+//         "steps": 10000
+//     },
+//     "summary": {
+//         "activityCalories": 1012,
+//         "caloriesBMR": 1686,
+//         "caloriesOut": 2592,
+//         "distances": [
+//             {
+//                 "activity": "total",
+//                 "distance": 6.72
+//             }
+//         ],
+//         "elevation": 30.48,
+//         "fairlyActiveMinutes": 12,
+//         "floors": 10,
+//         "lightlyActiveMinutes": 226,
+//         "marginalCalories": 593,
+//         "restingHeartRate": 58,
+//         "sedentaryMinutes": 655,
+//         "steps": 8922,
+//         "veryActiveMinutes": 21
+//     }
+// }
+type ActivitySummaryResult struct {
+	Goals   ActivityGoals   `json:"goals"`
+	Summary ActivitySummary `json:"summary"`
+}
+
+// Sample:
+//
+// {
+//     "activeMinutes": 30,
+//     "caloriesOut": 2826,
+//     "distance": 8.05,
+//     "floors": 10,
+//     "steps": 10000
+// }
+type ActivityGoals struct {
+	ActiveMinutes int     `json:"activeMinutes"`
+	CaloriesOut   int     `json:"caloriesOut"`
+	Distance      float64 `json:"distance"`
+	Floors        int     `json:"floors"`
+	Steps         int     `json:"steps"`
+}
+
+// Sample:
+//
+// {
+//     "activityCalories": 1012,
+//     "caloriesBMR": 1686,
+//     "caloriesOut": 2592,
+//     "distances": [
+//         {
+//             "activity": "total",
+//             "distance": 6.72
+//         }
+//     ],
+//     "elevation": 30.48,
+//     "fairlyActiveMinutes": 12,
+//     "floors": 10,
+//     "lightlyActiveMinutes": 226,
+//     "marginalCalories": 593,
+//     "restingHeartRate": 58,
+//     "sedentaryMinutes": 655,
+//     "steps": 8922,
+//     "veryActiveMinutes": 21
+// }
+type ActivitySummary struct {
+	ActivityCalories     int                `json:"activityCalories"`
+	CaloriesBMR          int                `json:"caloriesBMR"`
+	CaloriesOut          int                `json:"caloriesOut"`
+	Distances            []ActivityDistance `json:"distances"`
+	Elevation            float64            `json:"elevation"`
+	FairlyActiveMinutes  int                `json:"fairlyActiveMinutes"`
+	Floors               int                `json:"floors"`
+	LightlyActiveMinutes int                `json:"lightlyActiveMinutes"`
+	MarginalCalories     int                `json:"marginalCalories"`
+	RestingHeartRate     int                `json:"restingHeartRate"`
+	SedentaryMinutes     int                `json:"sedentaryMinutes"`
+	Steps                int                `json:"steps"`
+	VeryActiveMinutes    int                `json:"veryActiveMinutes"`
+}
+
+// TotalDistance returns the distance of the activity "total".
+func (s ActivitySummary) TotalDistance() float64 {
+	for _, distance := range s.Distances {
+		if distance.Activity == "total" {
+			return distance.Distance
+		}
+	}
+	return 0
+}
+
+// Sample:
+//
+// {
+//     "activity": "total",
+//     "distance": 6.72
+// }
+type ActivityDistance struct {
+	Activity string  `json:"activity"`
+	Distance float64 `json:"distance"`
+}
@@ -0,0 +1,214 @@
+package fitbit
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ActivityLogStartTimeFormat is the layout of the start time of an ActivityLog.
+const ActivityLogStartTimeFormat = "2006-01-02T15:04:05.000-07:00"
+
+// maxActivityLogListLimit is the maximum page size allowed by the Fitbit API.
+const maxActivityLogListLimit = 100
+
+// SortOrder describes the order of paginated lists.
+type SortOrder string
+
+const (
+	SortAscending  SortOrder = "asc"
+	SortDescending SortOrder = "desc"
+)
+
+// ActivityLogListOptions contains the parameters of the activity log list.
+// Exactly one of BeforeDate and AfterDate must be set. The Fitbit API
+// requires to sort descending for BeforeDate and ascending for AfterDate.
+type ActivityLogListOptions struct {
+	BeforeDate time.Time
+	AfterDate  time.Time
+	Sort       SortOrder
+	Offset     int
+	Limit      int
+}
+
+func (o ActivityLogListOptions) query() (url.Values, error) {
+	query := make(url.Values)
+	switch {
+	case !o.BeforeDate.IsZero() && !o.AfterDate.IsZero():
+		return nil, fmt.Errorf("only one of before date and after date must be set")
+	case !o.BeforeDate.IsZero():
+		query.Set("beforeDate", formatDate(o.BeforeDate))
+	case !o.AfterDate.IsZero():
+		query.Set("afterDate", formatDate(o.AfterDate))
+	default:
+		return nil, fmt.Errorf("one of before date and after date must be set")
+	}
+	if o.Limit < 1 || o.Limit > maxActivityLogListLimit {
+		return nil, fmt.Errorf("limit must be between 1 and %d", maxActivityLogListLimit)
+	}
+	query.Set("sort", string(o.Sort))
+	query.Set("offset", strconv.Itoa(o.Offset))
+	query.Set("limit", strconv.Itoa(o.Limit))
+	return query, nil
+}
+
+// ActivityLogList returns a single page of the activity log list.
+func (c *Client) ActivityLogList(ctx context.Context, opts ActivityLogListOptions) (*ActivityLogListResult, error) {
+	query, err := opts.query()
+	if err != nil {
+		return nil, fmt.Errorf("invalid activity log list options: %w", err)
+	}
+	var result ActivityLogListResult
+	if err := c.get(ctx, "/1/user/-/activities/list.json", query, &result); err != nil {
+		return nil, fmt.Errorf("error getting activity log list: %w", err)
+	}
+	return &result, nil
+}
+
+// NextActivityLogList returns the page of the activity log list following
+// the given pagination. It returns nil if there is no next page.
+func (c *Client) NextActivityLogList(ctx context.Context, pagination Pagination) (*ActivityLogListResult, error) {
+	if pagination.Next == "" {
+		return nil, nil
+	}
+	next, err := url.Parse(pagination.Next)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing next page url: %w", err)
+	}
+	var result ActivityLogListResult
+	if err := c.get(ctx, next.Path, next.Query(), &result); err != nil {
+		return nil, fmt.Errorf("error getting activity log list: %w", err)
+	}
+	return &result, nil
+}
+
+// RecentActivityLogsRequests returns the maximum number of requests sent by
+// RecentActivityLogs to fetch limit activity logs.
+func RecentActivityLogsRequests(limit int) int {
+	if limit < 1 {
+		return 1
+	}
+	return (limit + maxActivityLogListLimit - 1) / maxActivityLogListLimit
+}
+
+// RecentActivityLogs returns up to limit activity logs started before the
+// given date, most recent first. It follows the pagination as needed.
+func (c *Client) RecentActivityLogs(ctx context.Context, before time.Time, limit int) ([]ActivityLog, error) {
+	pageSize := limit
+	if pageSize > maxActivityLogListLimit {
+		pageSize = maxActivityLogListLimit
+	}
+	result, err := c.ActivityLogList(ctx, ActivityLogListOptions{
+		BeforeDate: before,
+		Sort:       SortDescending,
+		Limit:      pageSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var activities []ActivityLog
+	for result != nil {
+		activities = append(activities, result.Activities...)
+		if len(activities) >= limit || len(result.Activities) == 0 {
+			break
+		}
+		result, err = c.NextActivityLogList(ctx, result.Pagination)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(activities) > limit {
+		activities = activities[:limit]
+	}
+	return activities, nil
+}
+
+// Sample:
+//
+// {
+//     "activities": [...],
+//     "pagination": {
+//         "beforeDate": "2021-08-02",
+//         "limit": 10,
+//         "next": "https://api.fitbit.com/1/user/-/activities/list.json?offset=10&limit=10&sort=desc&beforeDate=2021-08-02",
+//         "offset": 0,
+//         "previous": "",
+//         "sort": "desc"
+//     }
+// }
+type ActivityLogListResult struct {
+	Activities []ActivityLog `json:"activities"`
+	Pagination Pagination    `json:"pagination"`
+}
+
+// Sample:
+//
+// {
+//     "beforeDate": "2021-08-02",
+//     "limit": 10,
+//     "next": "https://api.fitbit.com/1/user/-/activities/list.json?offset=10&limit=10&sort=desc&beforeDate=2021-08-02",
+//     "offset": 0,
+//     "previous": "",
+//     "sort": "desc"
+// }
+type Pagination struct {
+	AfterDate  string    `json:"afterDate,omitempty"`
+	BeforeDate string    `json:"beforeDate,omitempty"`
+	Limit      int       `json:"limit"`
+	Next       string    `json:"next"`
+	Offset     int       `json:"offset"`
+	Previous   string    `json:"previous"`
+	Sort       SortOrder `json:"sort"`
+}
+
+// Sample:
+//
+// {
+//     "activeDuration": 1845000,
+//     "activityName": "Run",
+//     "activityTypeId": 90009,
+//     "averageHeartRate": 152,
+//     "calories": 402,
+//     "distance": 5.12,
+//     "distanceUnit": "Kilometer",
+//     "duration": 1845000,
+//     "heartRateZones": [
+//         {
+//             "max": 118,
+//             "min": 30,
+//             "minutes": 1,
+//             "name": "Out of Range"
+//         }
+//     ],
+//     "logId": 41880298412,
+//     "logType": "auto_detected",
+//     "startTime": "2021-08-01T07:12:41.000+02:00",
+//     "steps": 5341
+// }
+type ActivityLog struct {
+	ActiveDuration   int64           `json:"activeDuration"`
+	ActivityName     string          `json:"activityName"`
+	ActivityTypeID   int64           `json:"activityTypeId"`
+	AverageHeartRate int             `json:"averageHeartRate"`
+	Calories         int             `json:"calories"`
+	Distance         float64         `json:"distance"`
+	DistanceUnit     string          `json:"distanceUnit"`
+	Duration         int64           `json:"duration"`
+	HeartRateZones   []HeartRateZone `json:"heartRateZones"`
+	LogID            int64           `json:"logId"`
+	LogType          string          `json:"logType"`
+	StartTime        string          `json:"startTime"`
+	Steps            int             `json:"steps"`
+}
+
+// DistanceMeters returns the distance of the activity in meters.
+func (a ActivityLog) DistanceMeters() float64 {
+	switch a.DistanceUnit {
+	case "Mile":
+		return a.Distance * 1609.344
+	default:
+		return a.Distance * 1000
+	}
+}
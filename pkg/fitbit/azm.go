@@ -0,0 +1,140 @@
+package fitbit
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ActiveZoneMinutesByDate returns the daily Active Zone Minutes of the given date.
+func (c *Client) ActiveZoneMinutesByDate(ctx context.Context, date time.Time) (*ActiveZoneMinutesResult, error) {
+	path := fmt.Sprintf("/1/user/-/activities/active-zone-minutes/date/%s/1d.json", formatDate(date))
+	var result ActiveZoneMinutesResult
+	if err := c.get(ctx, path, nil, &result); err != nil {
+		return nil, fmt.Errorf("error getting active zone minutes: %w", err)
+	}
+	return &result, nil
+}
+
+// ActiveZoneMinutesIntraday returns the intraday Active Zone Minutes of date
+// within the time window from start to end at the given detail level.
+func (c *Client) ActiveZoneMinutesIntraday(ctx context.Context, date time.Time, detailLevel DetailLevel, start, end time.Time) (*ActiveZoneMinutesIntradayResult, error) {
+	if detailLevel == DetailLevel1Sec {
+		return nil, fmt.Errorf("detail level %s not supported for active zone minutes", detailLevel)
+	}
+	path := intradayPath("active-zone-minutes", date, detailLevel, start, end)
+	var result ActiveZoneMinutesIntradayResult
+	if err := c.get(ctx, path, nil, &result); err != nil {
+		return nil, fmt.Errorf("error getting intraday active zone minutes: %w", err)
+	}
+	return &result, nil
+}
+
+// Sample:
+//
+// {
+//     "activities-active-zone-minutes": [
+//         {
+//             "dateTime": "2021-08-01",
+//             "value": {
+//                 "activeZoneMinutes": 102,
+//                 "fatBurnActiveZoneMinutes": 90,
+//                 "cardioActiveZoneMinutes": 12,
+//                 "peakActiveZoneMinutes": 0
+//             }
+//         }
+//     ]
+// }
+type ActiveZoneMinutesResult struct {
+	ActiveZoneMinutes []ActiveZoneMinutes `json:"activities-active-zone-minutes"`
+}
+
+// Sample:
+//
+// {
+//     "dateTime": "2021-08-01",
+//     "value": {
+//         "activeZoneMinutes": 102,
+//         "fatBurnActiveZoneMinutes": 90,
+//         "cardioActiveZoneMinutes": 12,
+//         "peakActiveZoneMinutes": 0
+//     }
+// }
+type ActiveZoneMinutes struct {
+	DateTime string                 `json:"dateTime"`
+	Value    ActiveZoneMinutesValue `json:"value"`
+}
+
+// Sample:
+//
+// {
+//     "activeZoneMinutes": 102,
+//     "fatBurnActiveZoneMinutes": 90,
+//     "cardioActiveZoneMinutes": 12,
+//     "peakActiveZoneMinutes": 0
+// }
+//
+// The zones match the heart rate zones "Fat Burn", "Cardio" and "Peak" of a
+// HeartRateZone. Minutes in the cardio and peak zone count twice towards the
+// total.
+type ActiveZoneMinutesValue struct {
+	ActiveZoneMinutes        int `json:"activeZoneMinutes"`
+	FatBurnActiveZoneMinutes int `json:"fatBurnActiveZoneMinutes"`
+	CardioActiveZoneMinutes  int `json:"cardioActiveZoneMinutes"`
+	PeakActiveZoneMinutes    int `json:"peakActiveZoneMinutes"`
+}
+
+// Sample:
+//
+// {
+//     "activities-active-zone-minutes-intraday": [
+//         {
+//             "dateTime": "2021-08-01",
+//             "minutes": [
+//                 {
+//                     "minute": "2021-08-01T13:08:00",
+//                     "value": {
+//                         "activeZoneMinutes": 1,
+//                         "fatBurnActiveZoneMinutes": 1
+//                     }
+//                 }
+//             ]
+//         }
+//     ]
+// }
+type ActiveZoneMinutesIntradayResult struct {
+	Intraday []ActiveZoneMinutesIntraday `json:"activities-active-zone-minutes-intraday"`
+}
+
+// Sample:
+//
+// {
+//     "dateTime": "2021-08-01",
+//     "minutes": [
+//         {
+//             "minute": "2021-08-01T13:08:00",
+//             "value": {
+//                 "activeZoneMinutes": 1,
+//                 "fatBurnActiveZoneMinutes": 1
+//             }
+//         }
+//     ]
+// }
+type ActiveZoneMinutesIntraday struct {
+	DateTime string                           `json:"dateTime"`
+	Minutes  []ActiveZoneMinutesIntradayValue `json:"minutes"`
+}
+
+// Sample:
+//
+// {
+//     "minute": "2021-08-01T13:08:00",
+//     "value": {
+//         "activeZoneMinutes": 1,
+//         "fatBurnActiveZoneMinutes": 1
+//     }
+// }
+type ActiveZoneMinutesIntradayValue struct {
+	Minute string                 `json:"minute"`
+	Value  ActiveZoneMinutesValue `json:"value"`
+}
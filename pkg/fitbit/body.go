@@ -0,0 +1,132 @@
+package fitbit
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// UnitSystem describes the unit system used by the Fitbit API for the
+// values of a response. It is requested with the Accept-Language header and
+// matches the unit settings of a Profile.
+type UnitSystem string
+
+const (
+	UnitSystemMetric UnitSystem = "METRIC"
+	UnitSystemUS     UnitSystem = "en_US"
+	UnitSystemUK     UnitSystem = "en_GB"
+)
+
+const (
+	kilogramsPerPound = 0.45359237
+	kilogramsPerStone = 6.35029318
+)
+
+// Kilograms converts a weight given in the unit system to kilograms.
+func (u UnitSystem) Kilograms(weight float64) float64 {
+	switch u {
+	case UnitSystemUS:
+		return weight * kilogramsPerPound
+	case UnitSystemUK:
+		return weight * kilogramsPerStone
+	default:
+		return weight
+	}
+}
+
+// WeightLogs returns the weight logs for the period ending at date. The
+// weights are given in the requested unit system.
+func (c *Client) WeightLogs(ctx context.Context, unitSystem UnitSystem, date time.Time, period Period) (*WeightLogResult, error) {
+	path := fmt.Sprintf("/1/user/-/body/log/weight/date/%s/%s.json", formatDate(date), period)
+	var result WeightLogResult
+	if err := c.getInUnitSystem(ctx, unitSystem, path, nil, &result); err != nil {
+		return nil, fmt.Errorf("error getting weight logs: %w", err)
+	}
+	result.UnitSystem = unitSystem
+	return &result, nil
+}
+
+// BodyFatLogs returns the body fat logs for the period ending at date.
+func (c *Client) BodyFatLogs(ctx context.Context, date time.Time, period Period) (*BodyFatLogResult, error) {
+	path := fmt.Sprintf("/1/user/-/body/log/fat/date/%s/%s.json", formatDate(date), period)
+	var result BodyFatLogResult
+	if err := c.get(ctx, path, nil, &result); err != nil {
+		return nil, fmt.Errorf("error getting body fat logs: %w", err)
+	}
+	return &result, nil
+}
+
+// Sample:
+//
+// {
+//     "weight": [
+//         {
+//             "bmi": 21.63,
+//             "date": "2021-08-01",
+//             "fat": 18.4,
+//             "logId": 1627804800000,
+//             "source": "Aria",
+//             "time": "07:12:41",
+//             "weight": 62.5
+//         }
+//     ]
+// }
+type WeightLogResult struct {
+	UnitSystem UnitSystem  `json:"-"`
+	Weight     []WeightLog `json:"weight"`
+}
+
+// Sample:
+//
+// {
+//     "bmi": 21.63,
+//     "date": "2021-08-01",
+//     "fat": 18.4,
+//     "logId": 1627804800000,
+//     "source": "Aria",
+//     "time": "07:12:41",
+//     "weight": 62.5
+// }
+type WeightLog struct {
+	BMI    float64 `json:"bmi"`
+	Date   string  `json:"date"`
+	Fat    float64 `json:"fat"`
+	LogID  int64   `json:"logId"`
+	Source string  `json:"source"`
+	Time   string  `json:"time"`
+	Weight float64 `json:"weight"`
+}
+
+// Sample:
+//
+// {
+//     "fat": [
+//         {
+//             "date": "2021-08-01",
+//             "fat": 18.4,
+//             "logId": 1627804800000,
+//             "source": "Aria",
+//             "time": "07:12:41"
+//         }
+//     ]
+// }
+type BodyFatLogResult struct {
+	Fat []BodyFatLog `json:"fat"`
+}
+
+// Sample:
+//
+// {
+//     "date": "2021-08-01",
+//     "fat": 18.4,
+//     "logId": 1627804800000,
+//     "source": "Aria",
+//     "time": "07:12:41"
+// }
+type BodyFatLog struct {
+	Date   string  `json:"date"`
+	Fat    float64 `json:"fat"`
+	LogID  int64   `json:"logId"`
+	Source string  `json:"source"`
+	Time   string  `json:"time"`
+}
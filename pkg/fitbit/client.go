@@ -0,0 +1,194 @@
+package fitbit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mitch000001/fitbit-exporter/pkg/http/oauth"
+)
+
+// DefaultBaseURL is the base URL of the Fitbit Web API.
+const DefaultBaseURL = "https://api.fitbit.com"
+
+const (
+	// DateFormat is the layout used by the Fitbit API for dates.
+	DateFormat = "2006-01-02"
+	// TimeFormat is the layout used by the Fitbit API for intraday time windows.
+	TimeFormat = "15:04"
+	// DateTimeFormat is the layout used by the Fitbit API for timestamps. The
+	// timestamps are given in the timezone of the user.
+	DateTimeFormat = "2006-01-02T15:04:05.000"
+	// DatasetTimeFormat is the layout used by the Fitbit API for the samples
+	// of intraday time series.
+	DatasetTimeFormat = "15:04:05"
+	// MinuteFormat is the layout used by the Fitbit API for the intervals of
+	// intraday Active Zone Minutes, given in the timezone of the user.
+	MinuteFormat = "2006-01-02T15:04:05"
+)
+
+// DetailLevel describes the granularity of intraday time series.
+type DetailLevel string
+
+const (
+	DetailLevel1Sec  DetailLevel = "1sec"
+	DetailLevel1Min  DetailLevel = "1min"
+	DetailLevel5Min  DetailLevel = "5min"
+	DetailLevel15Min DetailLevel = "15min"
+)
+
+// Duration returns the duration of a single interval at the detail level.
+func (d DetailLevel) Duration() time.Duration {
+	switch d {
+	case DetailLevel1Sec:
+		return time.Second
+	case DetailLevel5Min:
+		return 5 * time.Minute
+	case DetailLevel15Min:
+		return 15 * time.Minute
+	default:
+		return time.Minute
+	}
+}
+
+// ParseDetailLevel returns the DetailLevel matching value.
+func ParseDetailLevel(value string) (DetailLevel, error) {
+	switch detailLevel := DetailLevel(value); detailLevel {
+	case DetailLevel1Sec, DetailLevel1Min, DetailLevel5Min, DetailLevel15Min:
+		return detailLevel, nil
+	default:
+		return "", fmt.Errorf("unknown detail level %q", value)
+	}
+}
+
+// Period describes the range of a time series ending at a given date.
+type Period string
+
+const (
+	Period1Day   Period = "1d"
+	Period7Days  Period = "7d"
+	Period30Days Period = "30d"
+	Period1Week  Period = "1w"
+	Period1Month Period = "1m"
+)
+
+// Client is a client for the Fitbit Web API. The HTTP client used for every
+// request is obtained from the ClientProvider, thus authorization and rate
+// limiting are handled there.
+type Client struct {
+	BaseURL        string
+	clientProvider oauth.ClientProvider
+}
+
+// NewClient returns a Client talking to the DefaultBaseURL.
+func NewClient(clientProvider oauth.ClientProvider) *Client {
+	return &Client{
+		BaseURL:        DefaultBaseURL,
+		clientProvider: clientProvider,
+	}
+}
+
+// Profile returns the profile of the authorized user.
+func (c *Client) Profile(ctx context.Context) (*Profile, error) {
+	var result ProfileResult
+	if err := c.get(ctx, "/1/user/-/profile.json", nil, &result); err != nil {
+		return nil, fmt.Errorf("error getting profile: %w", err)
+	}
+	return &result.User, nil
+}
+
+// Devices returns the devices paired with the account of the authorized user.
+func (c *Client) Devices(ctx context.Context) ([]Device, error) {
+	var result []Device
+	if err := c.get(ctx, "/1/user/-/devices.json", nil, &result); err != nil {
+		return nil, fmt.Errorf("error getting devices: %w", err)
+	}
+	return result, nil
+}
+
+func (c *Client) get(ctx context.Context, path string, query url.Values, v interface{}) error {
+	return c.do(ctx, path, query, nil, v)
+}
+
+func (c *Client) getInUnitSystem(ctx context.Context, unitSystem UnitSystem, path string, query url.Values, v interface{}) error {
+	header := make(http.Header)
+	if unitSystem != "" && unitSystem != UnitSystemMetric {
+		header.Set("Accept-Language", string(unitSystem))
+	}
+	return c.do(ctx, path, query, header, v)
+}
+
+func (c *Client) do(ctx context.Context, path string, query url.Values, header http.Header, v interface{}) error {
+	u, err := url.Parse(strings.TrimSuffix(c.BaseURL, "/") + path)
+	if err != nil {
+		return fmt.Errorf("error parsing request url: %w", err)
+	}
+	if query != nil {
+		u.RawQuery = query.Encode()
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return fmt.Errorf("error creating request: %w", err)
+	}
+	for key, values := range header {
+		request.Header[key] = values
+	}
+	client, err := c.clientProvider.Client(ctx)
+	if err != nil {
+		return fmt.Errorf("error getting client: %w", err)
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return fmt.Errorf("error sending request: %w", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return newAPIError(response)
+	}
+	if err := json.NewDecoder(response.Body).Decode(v); err != nil {
+		return fmt.Errorf("error parsing response: %w", err)
+	}
+	return nil
+}
+
+// ParseDateTime parses a timestamp returned by the Fitbit API within the given location.
+func ParseDateTime(value string, loc *time.Location) (time.Time, error) {
+	return time.ParseInLocation(DateTimeFormat, value, loc)
+}
+
+// ParseDatasetTime parses the time of an intraday sample taken at the day of
+// date. The time is returned within the location of date.
+func ParseDatasetTime(date time.Time, value string) (time.Time, error) {
+	t, err := time.Parse(DatasetTimeFormat, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Date(date.Year(), date.Month(), date.Day(), t.Hour(), t.Minute(), t.Second(), 0, date.Location()), nil
+}
+
+func formatDate(date time.Time) string {
+	return date.Format(DateFormat)
+}
+
+func formatTime(t time.Time) string {
+	return t.Format(TimeFormat)
+}
+
+func newAPIError(response *http.Response) error {
+	apiErr := &APIError{
+		StatusCode: response.StatusCode,
+	}
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return apiErr
+	}
+	if err := json.Unmarshal(body, apiErr); err != nil {
+		apiErr.Errors = nil
+	}
+	return apiErr
+}
@@ -0,0 +1,104 @@
+package fitbit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type staticClientProvider struct {
+	client *http.Client
+}
+
+func (p staticClientProvider) Client(context.Context) (*http.Client, error) {
+	return p.client, nil
+}
+
+// newTestClient returns a Client talking to a server answering every request
+// with the status code and body.
+func newTestClient(t *testing.T, statusCode int, body string) *Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(statusCode)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	client := NewClient(staticClientProvider{client: server.Client()})
+	client.BaseURL = server.URL
+	return client
+}
+
+func TestClientAPIError(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		body       string
+		errorType  string
+		message    string
+	}{
+		{
+			name:       "expired token",
+			statusCode: http.StatusUnauthorized,
+			body:       `{"errors":[{"errorType":"expired_token","message":"Access token expired: eyJhbGciOiJIUzI1NiJ9."}],"success":false}`,
+			errorType:  "expired_token",
+			message:    "error getting profile: fitbit api error: 401 Unauthorized: expired_token: Access token expired: eyJhbGciOiJIUzI1NiJ9.",
+		},
+		{
+			name:       "validation error",
+			statusCode: http.StatusBadRequest,
+			body:       `{"errors":[{"errorType":"validation","fieldName":"date","message":"Invalid date"}],"success":false}`,
+			errorType:  "validation",
+			message:    "error getting profile: fitbit api error: 400 Bad Request: validation (date): Invalid date",
+		},
+		{
+			name:       "rate limited without body",
+			statusCode: http.StatusTooManyRequests,
+			body:       "",
+			message:    "error getting profile: fitbit api error: 429 Too Many Requests",
+		},
+		{
+			name:       "server error without json",
+			statusCode: http.StatusBadGateway,
+			body:       "<html>Bad Gateway</html>",
+			message:    "error getting profile: fitbit api error: 502 Bad Gateway",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := newTestClient(t, test.statusCode, test.body)
+
+			_, err := client.Profile(context.Background())
+
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("expected an APIError, got %v", err)
+			}
+			if apiErr.StatusCode != test.statusCode {
+				t.Errorf("expected status code %d, got %d", test.statusCode, apiErr.StatusCode)
+			}
+			if test.errorType != "" && !apiErr.HasErrorType(test.errorType) {
+				t.Errorf("expected error type %q, got %+v", test.errorType, apiErr.Errors)
+			}
+			if test.errorType == "" && len(apiErr.Errors) != 0 {
+				t.Errorf("expected no error details, got %+v", apiErr.Errors)
+			}
+			if err.Error() != test.message {
+				t.Errorf("expected message %q, got %q", test.message, err.Error())
+			}
+		})
+	}
+}
+
+func TestClientInvalidResponse(t *testing.T) {
+	client := newTestClient(t, http.StatusOK, `{"user":`)
+
+	_, err := client.Profile(context.Background())
+
+	var apiErr *APIError
+	if err == nil || errors.As(err, &apiErr) {
+		t.Fatalf("expected a parse error, got %v", err)
+	}
+}
@@ -0,0 +1,24 @@
+package fitbit
+
+// Sample:
+//
+// {
+//     "battery": "High",
+//     "batteryLevel": 87,
+//     "deviceVersion": "Charge 4",
+//     "features": [],
+//     "id": "1234567890",
+//     "lastSyncTime": "2021-08-01T13:08:41.000",
+//     "mac": "AB12CD34EF56",
+//     "type": "TRACKER"
+// }
+type Device struct {
+	Battery       string   `json:"battery"`
+	BatteryLevel  int      `json:"batteryLevel"`
+	DeviceVersion string   `json:"deviceVersion"`
+	Features      []string `json:"features"`
+	ID            string   `json:"id"`
+	LastSyncTime  string   `json:"lastSyncTime"`
+	Mac           string   `json:"mac"`
+	Type          string   `json:"type"`
+}
@@ -0,0 +1,57 @@
+package fitbit
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// APIError is returned by the Client for every non 2xx response.
+//
+// Sample:
+//
+// {
+//     "errors": [
+//         {
+//             "errorType": "expired_token",
+//             "message": "Access token expired: eyJhbGciOiJIUzI1NiJ9. Visit https://dev.fitbit.com/docs/oauth2 for more information on the Fitbit Web API authorization process."
+//         }
+//     ],
+//     "success": false
+// }
+type APIError struct {
+	StatusCode int           `json:"-"`
+	Errors     []ErrorDetail `json:"errors"`
+}
+
+// ErrorDetail is a single entry of the errors returned by the Fitbit API.
+type ErrorDetail struct {
+	ErrorType string `json:"errorType"`
+	FieldName string `json:"fieldName,omitempty"`
+	Message   string `json:"message"`
+}
+
+func (e *APIError) Error() string {
+	if len(e.Errors) == 0 {
+		return fmt.Sprintf("fitbit api error: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	messages := make([]string, 0, len(e.Errors))
+	for _, detail := range e.Errors {
+		if detail.FieldName != "" {
+			messages = append(messages, fmt.Sprintf("%s (%s): %s", detail.ErrorType, detail.FieldName, detail.Message))
+			continue
+		}
+		messages = append(messages, fmt.Sprintf("%s: %s", detail.ErrorType, detail.Message))
+	}
+	return fmt.Sprintf("fitbit api error: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), strings.Join(messages, "; "))
+}
+
+// HasErrorType returns true if any of the error details is of the given type.
+func (e *APIError) HasErrorType(errorType string) bool {
+	for _, detail := range e.Errors {
+		if detail.ErrorType == errorType {
+			return true
+		}
+	}
+	return false
+}
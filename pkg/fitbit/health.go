@@ -0,0 +1,267 @@
+package fitbit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SpO2ByDate returns the SpO2 summary of the sleep ending at date.
+func (c *Client) SpO2ByDate(ctx context.Context, date time.Time) (*SpO2Result, error) {
+	path := fmt.Sprintf("/1/user/-/spo2/date/%s.json", formatDate(date))
+	var result SpO2Result
+	if err := c.get(ctx, path, nil, &result); err != nil {
+		return nil, fmt.Errorf("error getting spo2: %w", err)
+	}
+	return &result, nil
+}
+
+// HeartRateVariabilityByDate returns the heart rate variability of the sleep ending at date.
+func (c *Client) HeartRateVariabilityByDate(ctx context.Context, date time.Time) (*HeartRateVariabilityResult, error) {
+	path := fmt.Sprintf("/1/user/-/hrv/date/%s.json", formatDate(date))
+	var result HeartRateVariabilityResult
+	if err := c.get(ctx, path, nil, &result); err != nil {
+		return nil, fmt.Errorf("error getting heart rate variability: %w", err)
+	}
+	return &result, nil
+}
+
+// BreathingRateByDate returns the breathing rate of the sleep ending at date.
+func (c *Client) BreathingRateByDate(ctx context.Context, date time.Time) (*BreathingRateResult, error) {
+	path := fmt.Sprintf("/1/user/-/br/date/%s.json", formatDate(date))
+	var result BreathingRateResult
+	if err := c.get(ctx, path, nil, &result); err != nil {
+		return nil, fmt.Errorf("error getting breathing rate: %w", err)
+	}
+	return &result, nil
+}
+
+// SkinTemperatureByDate returns the skin temperature variation of the sleep ending at date.
+func (c *Client) SkinTemperatureByDate(ctx context.Context, date time.Time) (*SkinTemperatureResult, error) {
+	path := fmt.Sprintf("/1/user/-/temp/skin/date/%s.json", formatDate(date))
+	var result SkinTemperatureResult
+	if err := c.get(ctx, path, nil, &result); err != nil {
+		return nil, fmt.Errorf("error getting skin temperature: %w", err)
+	}
+	return &result, nil
+}
+
+// CardioFitnessByDate returns the cardio fitness score of the given date.
+func (c *Client) CardioFitnessByDate(ctx context.Context, date time.Time) (*CardioFitnessResult, error) {
+	path := fmt.Sprintf("/1/user/-/cardioscore/date/%s.json", formatDate(date))
+	var result CardioFitnessResult
+	if err := c.get(ctx, path, nil, &result); err != nil {
+		return nil, fmt.Errorf("error getting cardio fitness: %w", err)
+	}
+	return &result, nil
+}
+
+// Sample:
+//
+// {
+//     "dateTime": "2021-10-04",
+//     "value": {
+//         "avg": 97.5,
+//         "min": 94.0,
+//         "max": 100.0
+//     }
+// }
+type SpO2Result struct {
+	DateTime string    `json:"dateTime"`
+	Value    SpO2Value `json:"value"`
+}
+
+// Sample:
+//
+// {
+//     "avg": 97.5,
+//     "min": 94.0,
+//     "max": 100.0
+// }
+type SpO2Value struct {
+	Avg float64 `json:"avg"`
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+}
+
+// Sample:
+//
+// {
+//     "hrv": [
+//         {
+//             "dateTime": "2021-10-25",
+//             "value": {
+//                 "dailyRmssd": 34.938,
+//                 "deepRmssd": 31.567
+//             }
+//         }
+//     ]
+// }
+type HeartRateVariabilityResult struct {
+	HRV []HeartRateVariability `json:"hrv"`
+}
+
+// Sample:
+//
+// {
+//     "dateTime": "2021-10-25",
+//     "value": {
+//         "dailyRmssd": 34.938,
+//         "deepRmssd": 31.567
+//     }
+// }
+type HeartRateVariability struct {
+	DateTime string                    `json:"dateTime"`
+	Value    HeartRateVariabilityValue `json:"value"`
+}
+
+// Sample:
+//
+// {
+//     "dailyRmssd": 34.938,
+//     "deepRmssd": 31.567
+// }
+type HeartRateVariabilityValue struct {
+	DailyRmssd float64 `json:"dailyRmssd"`
+	DeepRmssd  float64 `json:"deepRmssd"`
+}
+
+// Sample:
+//
+// {
+//     "br": [
+//         {
+//             "dateTime": "2021-10-25",
+//             "value": {
+//                 "breathingRate": 17.8
+//             }
+//         }
+//     ]
+// }
+type BreathingRateResult struct {
+	BreathingRate []BreathingRate `json:"br"`
+}
+
+// Sample:
+//
+// {
+//     "dateTime": "2021-10-25",
+//     "value": {
+//         "breathingRate": 17.8
+//     }
+// }
+type BreathingRate struct {
+	DateTime string             `json:"dateTime"`
+	Value    BreathingRateValue `json:"value"`
+}
+
+// Sample:
+//
+// {
+//     "breathingRate": 17.8
+// }
+type BreathingRateValue struct {
+	BreathingRate float64 `json:"breathingRate"`
+}
+
+// Sample:
+//
+// {
+//     "tempSkin": [
+//         {
+//             "dateTime": "2021-10-04",
+//             "logType": "dedicated_temp_sensor",
+//             "value": {
+//                 "nightlyRelative": -0.77
+//             }
+//         }
+//     ]
+// }
+type SkinTemperatureResult struct {
+	TempSkin []SkinTemperature `json:"tempSkin"`
+}
+
+// Sample:
+//
+// {
+//     "dateTime": "2021-10-04",
+//     "logType": "dedicated_temp_sensor",
+//     "value": {
+//         "nightlyRelative": -0.77
+//     }
+// }
+type SkinTemperature struct {
+	DateTime string               `json:"dateTime"`
+	LogType  string               `json:"logType"`
+	Value    SkinTemperatureValue `json:"value"`
+}
+
+// Sample:
+//
+// {
+//     "nightlyRelative": -0.77
+// }
+type SkinTemperatureValue struct {
+	NightlyRelative float64 `json:"nightlyRelative"`
+}
+
+// Sample:
+//
+// {
+//     "cardioScore": [
+//         {
+//             "dateTime": "2021-10-25",
+//             "value": {
+//                 "vo2Max": "47-51"
+//             }
+//         }
+//     ]
+// }
+type CardioFitnessResult struct {
+	CardioScore []CardioScore `json:"cardioScore"`
+}
+
+// Sample:
+//
+// {
+//     "dateTime": "2021-10-25",
+//     "value": {
+//         "vo2Max": "47-51"
+//     }
+// }
+type CardioScore struct {
+	DateTime string           `json:"dateTime"`
+	Value    CardioScoreValue `json:"value"`
+}
+
+// Sample:
+//
+// {
+//     "vo2Max": "47-51"
+// }
+//
+// The VO2 max is given as range if no GPS data is available and as single
+// value otherwise.
+type CardioScoreValue struct {
+	VO2Max string `json:"vo2Max"`
+}
+
+// Range returns the lower and upper bound of the VO2 max. Both bounds are
+// equal if the VO2 max is given as single value.
+func (v CardioScoreValue) Range() (float64, float64, error) {
+	parts := strings.SplitN(v.VO2Max, "-", 2)
+	low, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error parsing vo2 max %q: %w", v.VO2Max, err)
+	}
+	if len(parts) == 1 {
+		return low, low, nil
+	}
+	high, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error parsing vo2 max %q: %w", v.VO2Max, err)
+	}
+	return low, high, nil
+}
@@ -0,0 +1,38 @@
+package fitbit
+
+import "testing"
+
+func TestCardioScoreValueRange(t *testing.T) {
+	tests := []struct {
+		vo2Max string
+		low    float64
+		high   float64
+		err    bool
+	}{
+		{vo2Max: "47-51", low: 47, high: 51},
+		{vo2Max: "47 - 51", low: 47, high: 51},
+		{vo2Max: "49.27", low: 49.27, high: 49.27},
+		{vo2Max: "", err: true},
+		{vo2Max: "-51", err: true},
+		{vo2Max: "47-", err: true},
+		{vo2Max: "47-high", err: true},
+	}
+	for _, test := range tests {
+		t.Run(test.vo2Max, func(t *testing.T) {
+			low, high, err := CardioScoreValue{VO2Max: test.vo2Max}.Range()
+
+			if test.err {
+				if err == nil {
+					t.Errorf("expected an error, got %v-%v", low, high)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if low != test.low || high != test.high {
+				t.Errorf("expected %v-%v, got %v-%v", test.low, test.high, low, high)
+			}
+		})
+	}
+}
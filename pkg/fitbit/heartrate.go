@@ -1,5 +1,42 @@
 package fitbit
 
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// HeartRateByDate returns the daily heart rate summaries for the period ending at date.
+func (c *Client) HeartRateByDate(ctx context.Context, date time.Time, period Period) (*HeartRateSummaryResult, error) {
+	path := fmt.Sprintf("/1/user/-/activities/heart/date/%s/%s.json", formatDate(date), period)
+	var result HeartRateSummaryResult
+	if err := c.get(ctx, path, nil, &result); err != nil {
+		return nil, fmt.Errorf("error getting heart rate: %w", err)
+	}
+	return &result, nil
+}
+
+// HeartRateByDateRange returns the daily heart rate summaries between start and end date.
+func (c *Client) HeartRateByDateRange(ctx context.Context, start, end time.Time) (*HeartRateSummaryResult, error) {
+	path := fmt.Sprintf("/1/user/-/activities/heart/date/%s/%s.json", formatDate(start), formatDate(end))
+	var result HeartRateSummaryResult
+	if err := c.get(ctx, path, nil, &result); err != nil {
+		return nil, fmt.Errorf("error getting heart rate: %w", err)
+	}
+	return &result, nil
+}
+
+// HeartRateIntraday returns the intraday heart rate time series of date
+// within the time window from start to end at the given detail level.
+func (c *Client) HeartRateIntraday(ctx context.Context, date time.Time, detailLevel DetailLevel, start, end time.Time) (*HeartRateResult, error) {
+	path := intradayPath("heart", date, detailLevel, start, end)
+	var result HeartRateResult
+	if err := c.get(ctx, path, nil, &result); err != nil {
+		return nil, fmt.Errorf("error getting intraday heart rate: %w", err)
+	}
+	return &result, nil
+}
+
 // Sample:
 //
 // {
@@ -58,6 +95,47 @@ type HeartActivity struct {
 	Value                string          `json:"value"`
 }
 
+// Sample:
+//
+// {
+//     "activities-heart": [
+//         {
+//             "dateTime": "2021-05-20",
+//             "value": {
+//                 "customHeartRateZones": [],
+//                 "heartRateZones": [
+//                     {
+//                         "caloriesOut": 1834.2349,
+//                         "max": 111,
+//                         "min": 30,
+//                         "minutes": 1285,
+//                         "name": "Out of Range"
+//                     }
+//                 ],
+//                 "restingHeartRate": 58
+//             }
+//         }
+//     ]
+// }
+type HeartRateSummaryResult struct {
+	Activities []HeartRateSummary `json:"activities-heart"`
+}
+
+// HeartRateSummary is the heart rate summary of a single day. Unlike the
+// summary returned along with intraday time series its value is an object.
+type HeartRateSummary struct {
+	DateTime string                `json:"dateTime"`
+	Value    HeartRateSummaryValue `json:"value"`
+}
+
+// HeartRateSummaryValue contains the heart rate zones and the resting heart
+// rate of a day. RestingHeartRate is zero if it could not be determined.
+type HeartRateSummaryValue struct {
+	CustomHeartRateZones []HeartRateZone `json:"customHeartRateZones"`
+	HeartRateZones       []HeartRateZone `json:"heartRateZones"`
+	RestingHeartRate     int             `json:"restingHeartRate"`
+}
+
 // Sample:
 //
 // {
@@ -0,0 +1,110 @@
+package fitbit
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestHeartRateByDateRange(t *testing.T) {
+	// Recorded from GET /1/user/-/activities/heart/date/2021-05-19/2021-05-20.json
+	client := newTestClient(t, http.StatusOK, `{
+  "activities-heart": [
+    {
+      "dateTime": "2021-05-19",
+      "value": {
+        "customHeartRateZones": [],
+        "heartRateZones": [
+          {"caloriesOut": 1834.2349, "max": 111, "min": 30, "minutes": 1285, "name": "Out of Range"},
+          {"caloriesOut": 488.8032, "max": 136, "min": 111, "minutes": 110, "name": "Fat Burn"},
+          {"caloriesOut": 146.7642, "max": 168, "min": 136, "minutes": 17, "name": "Cardio"},
+          {"caloriesOut": 0, "max": 220, "min": 168, "minutes": 0, "name": "Peak"}
+        ],
+        "restingHeartRate": 58
+      }
+    },
+    {
+      "dateTime": "2021-05-20",
+      "value": {
+        "customHeartRateZones": [],
+        "heartRateZones": [
+          {"caloriesOut": 968.1219, "max": 111, "min": 30, "minutes": 741, "name": "Out of Range"},
+          {"caloriesOut": 12.0456, "max": 136, "min": 111, "minutes": 2, "name": "Fat Burn"},
+          {"caloriesOut": 0, "max": 168, "min": 136, "minutes": 0, "name": "Cardio"},
+          {"caloriesOut": 0, "max": 220, "min": 168, "minutes": 0, "name": "Peak"}
+        ]
+      }
+    }
+  ]
+}`)
+	start := time.Date(2021, 5, 19, 0, 0, 0, 0, time.UTC)
+
+	result, err := client.HeartRateByDateRange(context.Background(), start, start.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(result.Activities) != 2 {
+		t.Fatalf("expected 2 days, got %d", len(result.Activities))
+	}
+	day := result.Activities[0]
+	if day.DateTime != "2021-05-19" {
+		t.Errorf("expected date %q, got %q", "2021-05-19", day.DateTime)
+	}
+	if day.Value.RestingHeartRate != 58 {
+		t.Errorf("expected resting heart rate 58, got %d", day.Value.RestingHeartRate)
+	}
+	if len(day.Value.HeartRateZones) != 4 {
+		t.Fatalf("expected 4 heart rate zones, got %d", len(day.Value.HeartRateZones))
+	}
+	expected := HeartRateZone{CaloriesOut: 488.8032, Max: 136, Min: 111, Minutes: 110, Name: "Fat Burn"}
+	if zone := day.Value.HeartRateZones[1]; zone != expected {
+		t.Errorf("expected zone %+v, got %+v", expected, zone)
+	}
+	// The resting heart rate is omitted until it can be determined.
+	if resting := result.Activities[1].Value.RestingHeartRate; resting != 0 {
+		t.Errorf("expected no resting heart rate, got %d", resting)
+	}
+}
+
+func TestHeartRateIntraday(t *testing.T) {
+	// Recorded from GET /1/user/-/activities/heart/date/2021-05-20/1d/1sec/time/13:00/13:10.json
+	client := newTestClient(t, http.StatusOK, `{
+  "activities-heart": [
+    {
+      "customHeartRateZones": [],
+      "dateTime": "2021-05-20",
+      "heartRateZones": [
+        {"caloriesOut": 55.26554, "max": 118, "min": 30, "minutes": 21, "name": "Out of Range"}
+      ],
+      "value": "99.58"
+    }
+  ],
+  "activities-heart-intraday": {
+    "dataset": [
+      {"time": "13:00:01", "value": 91},
+      {"time": "13:08:41", "value": 122}
+    ],
+    "datasetInterval": 1,
+    "datasetType": "second"
+  }
+}`)
+	date := time.Date(2021, 5, 20, 0, 0, 0, 0, time.UTC)
+
+	result, err := client.HeartRateIntraday(context.Background(), date, DetailLevel1Sec, date.Add(13*time.Hour), date.Add(13*time.Hour+10*time.Minute))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(result.Activities) != 1 || result.Activities[0].Value != "99.58" {
+		t.Errorf("expected a single summary with value %q, got %+v", "99.58", result.Activities)
+	}
+	dataset := result.ActivitiesIntraDay.Dataset
+	if len(dataset) != 2 {
+		t.Fatalf("expected 2 samples, got %d", len(dataset))
+	}
+	if latest := dataset[1]; latest.Time != "13:08:41" || latest.Value != 122 {
+		t.Errorf("expected latest sample 122 at 13:08:41, got %+v", latest)
+	}
+}
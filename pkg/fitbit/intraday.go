@@ -0,0 +1,149 @@
+package fitbit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// IntradayResource is an activity resource which can be fetched as intraday
+// time series.
+type IntradayResource string
+
+const (
+	IntradaySteps     IntradayResource = "steps"
+	IntradayCalories  IntradayResource = "calories"
+	IntradayDistance  IntradayResource = "distance"
+	IntradayFloors    IntradayResource = "floors"
+	IntradayElevation IntradayResource = "elevation"
+)
+
+// IntradayResources contains all resources available as intraday time series.
+var IntradayResources = []IntradayResource{
+	IntradaySteps,
+	IntradayCalories,
+	IntradayDistance,
+	IntradayFloors,
+	IntradayElevation,
+}
+
+// IntradayTimeSeries returns the intraday time series of the resource for
+// date within the time window from start to end at the given detail level.
+// Activity resources are only available at 1min, 5min and 15min detail level.
+func (c *Client) IntradayTimeSeries(ctx context.Context, resource IntradayResource, date time.Time, detailLevel DetailLevel, start, end time.Time) (*IntradayResult, error) {
+	if detailLevel == DetailLevel1Sec {
+		return nil, fmt.Errorf("detail level %s not supported for resource %s", detailLevel, resource)
+	}
+	path := intradayPath(string(resource), date, detailLevel, start, end)
+	var raw map[string]json.RawMessage
+	if err := c.get(ctx, path, nil, &raw); err != nil {
+		return nil, fmt.Errorf("error getting intraday %s: %w", resource, err)
+	}
+	result := IntradayResult{
+		Resource: resource,
+	}
+	if activities, ok := raw[fmt.Sprintf("activities-%s", resource)]; ok {
+		if err := json.Unmarshal(activities, &result.Activities); err != nil {
+			return nil, fmt.Errorf("error parsing %s: %w", resource, err)
+		}
+	}
+	if intraday, ok := raw[fmt.Sprintf("activities-%s-intraday", resource)]; ok {
+		if err := json.Unmarshal(intraday, &result.Intraday); err != nil {
+			return nil, fmt.Errorf("error parsing intraday %s: %w", resource, err)
+		}
+	}
+	return &result, nil
+}
+
+func intradayPath(resource string, date time.Time, detailLevel DetailLevel, start, end time.Time) string {
+	return fmt.Sprintf(
+		"/1/user/-/activities/%s/date/%s/1d/%s/time/%s/%s.json",
+		resource, formatDate(date), detailLevel, formatTime(start), formatTime(end),
+	)
+}
+
+// IntradayResult is the intraday time series of a single resource. The
+// response keys depend on the resource, e.g. for steps:
+//
+// {
+//     "activities-steps": [
+//         {
+//             "dateTime": "2021-08-01",
+//             "value": "8922"
+//         }
+//     ],
+//     "activities-steps-intraday": {
+//         "dataset": [
+//             {
+//                 "time": "13:08:00",
+//                 "value": 42
+//             }
+//         ],
+//         "datasetInterval": 1,
+//         "datasetType": "minute"
+//     }
+// }
+type IntradayResult struct {
+	Resource   IntradayResource
+	Activities []IntradayActivity
+	Intraday   IntradayDataset
+}
+
+// Sample:
+//
+// {
+//     "dateTime": "2021-08-01",
+//     "value": "8922"
+// }
+type IntradayActivity struct {
+	DateTime string `json:"dateTime"`
+	Value    string `json:"value"`
+}
+
+// Sample:
+//
+// {
+//     "dataset": [
+//         {
+//             "time": "13:08:00",
+//             "value": 42
+//         }
+//     ],
+//     "datasetInterval": 1,
+//     "datasetType": "minute"
+// }
+type IntradayDataset struct {
+	Dataset         []IntradayDatasetValue `json:"dataset"`
+	DatasetInterval int                    `json:"datasetInterval"`
+	DatasetType     string                 `json:"datasetType"`
+}
+
+// Interval returns the duration covered by every sample of the dataset.
+func (d IntradayDataset) Interval() time.Duration {
+	unit := time.Minute
+	if d.DatasetType == "second" {
+		unit = time.Second
+	}
+	if d.DatasetInterval <= 0 {
+		return unit
+	}
+	return time.Duration(d.DatasetInterval) * unit
+}
+
+// Sample:
+//
+// {
+//     "level": 0,
+//     "mets": 10,
+//     "time": "13:08:00",
+//     "value": 42
+// }
+//
+// Level and mets are only set for the calories resource.
+type IntradayDatasetValue struct {
+	Level int     `json:"level,omitempty"`
+	Mets  int     `json:"mets,omitempty"`
+	Time  string  `json:"time"`
+	Value float64 `json:"value"`
+}
@@ -0,0 +1,126 @@
+package fitbit
+
+import (
+	"context"
+	"fmt"
+)
+
+// FriendsLeaderboard returns the 7 day step leaderboard of the authorized
+// user and their friends.
+func (c *Client) FriendsLeaderboard(ctx context.Context) (*LeaderboardResult, error) {
+	var result LeaderboardResult
+	if err := c.get(ctx, "/1.1/user/-/leaderboard/friends.json", nil, &result); err != nil {
+		return nil, fmt.Errorf("error getting friends leaderboard: %w", err)
+	}
+	return &result, nil
+}
+
+// Sample:
+//
+// {
+//     "data": [
+//         {
+//             "attributes": {
+//                 "step-rank": 1,
+//                 "step-summary": 75312
+//             },
+//             "id": "ABC123",
+//             "relationships": {
+//                 "user": {
+//                     "data": {
+//                         "id": "ABC123",
+//                         "type": "person"
+//                     }
+//                 }
+//             },
+//             "type": "ranked-user"
+//         }
+//     ],
+//     "included": [
+//         {
+//             "attributes": {
+//                 "avatar": "https://static0.fitbit.com/images/profile/defaultProfile_100.png",
+//                 "child": false,
+//                 "friend": true,
+//                 "name": "Jane D."
+//             },
+//             "id": "ABC123",
+//             "type": "person"
+//         }
+//     ]
+// }
+//
+// Users without steps within the last 7 days are of type "inactive-user"
+// and have no rank.
+type LeaderboardResult struct {
+	Data     []LeaderboardEntry  `json:"data"`
+	Included []LeaderboardPerson `json:"included"`
+}
+
+// Sample:
+//
+// {
+//     "attributes": {
+//         "step-rank": 1,
+//         "step-summary": 75312
+//     },
+//     "id": "ABC123",
+//     "type": "ranked-user"
+// }
+type LeaderboardEntry struct {
+	Attributes LeaderboardEntryAttributes `json:"attributes"`
+	ID         string                     `json:"id"`
+	Type       string                     `json:"type"`
+}
+
+// Sample:
+//
+// {
+//     "step-rank": 1,
+//     "step-summary": 75312
+// }
+type LeaderboardEntryAttributes struct {
+	StepRank    int `json:"step-rank"`
+	StepSummary int `json:"step-summary"`
+}
+
+// Sample:
+//
+// {
+//     "attributes": {
+//         "child": false,
+//         "friend": true,
+//         "name": "Jane D."
+//     },
+//     "id": "ABC123",
+//     "type": "person"
+// }
+type LeaderboardPerson struct {
+	Attributes LeaderboardPersonAttributes `json:"attributes"`
+	ID         string                      `json:"id"`
+	Type       string                      `json:"type"`
+}
+
+// Sample:
+//
+// {
+//     "avatar": "https://static0.fitbit.com/images/profile/defaultProfile_100.png",
+//     "child": false,
+//     "friend": true,
+//     "name": "Jane D."
+// }
+type LeaderboardPersonAttributes struct {
+	Avatar string `json:"avatar"`
+	Child  bool   `json:"child"`
+	Friend bool   `json:"friend"`
+	Name   string `json:"name"`
+}
+
+// Names returns the names of the included persons by ID.
+func (r LeaderboardResult) Names() map[string]string {
+	names := make(map[string]string, len(r.Included))
+	for _, person := range r.Included {
+		names[person.ID] = person.Attributes.Name
+	}
+	return names
+}
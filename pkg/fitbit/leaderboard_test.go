@@ -0,0 +1,49 @@
+package fitbit
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestFriendsLeaderboard(t *testing.T) {
+	// Recorded from GET /1.1/user/-/leaderboard/friends.json
+	client := newTestClient(t, http.StatusOK, `{
+  "data": [
+    {"attributes": {"step-rank": 1, "step-summary": 75312}, "id": "ABC123", "type": "ranked-user"},
+    {"attributes": {}, "id": "DEF456", "type": "inactive-user"}
+  ],
+  "included": [
+    {"attributes": {"child": false, "friend": true, "name": "Jane D."}, "id": "ABC123", "type": "person"},
+    {"attributes": {"child": false, "friend": true, "name": "John D."}, "id": "DEF456", "type": "person"}
+  ]
+}`)
+
+	result, err := client.FriendsLeaderboard(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		id          string
+		name        string
+		stepRank    int
+		stepSummary int
+	}{
+		{id: "ABC123", name: "Jane D.", stepRank: 1, stepSummary: 75312},
+		{id: "DEF456", name: "John D."},
+	}
+	if len(result.Data) != len(tests) {
+		t.Fatalf("expected %d entries, got %d", len(tests), len(result.Data))
+	}
+	names := result.Names()
+	for i, test := range tests {
+		entry := result.Data[i]
+		if entry.ID != test.id || names[entry.ID] != test.name {
+			t.Errorf("expected entry %d to be %s (%s), got %s (%s)", i, test.id, test.name, entry.ID, names[entry.ID])
+		}
+		if entry.Attributes.StepRank != test.stepRank || entry.Attributes.StepSummary != test.stepSummary {
+			t.Errorf("expected %s to have rank %d with %d steps, got %+v", test.id, test.stepRank, test.stepSummary, entry.Attributes)
+		}
+	}
+}
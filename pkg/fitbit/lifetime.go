@@ -0,0 +1,108 @@
+package fitbit
+
+import (
+	"context"
+	"fmt"
+)
+
+// LifetimeStats returns the lifetime statistics and best days of the authorized user.
+func (c *Client) LifetimeStats(ctx context.Context) (*LifetimeStatsResult, error) {
+	var result LifetimeStatsResult
+	if err := c.get(ctx, "/1/user/-/activities.json", nil, &result); err != nil {
+		return nil, fmt.Errorf("error getting lifetime stats: %w", err)
+	}
+	return &result, nil
+}
+
+// Sample:
+//
+// {
+//     "best": {
+//         "total": {...},
+//         "tracker": {...}
+//     },
+//     "lifetime": {
+//         "total": {...},
+//         "tracker": {...}
+//     }
+// }
+//
+// Total contains tracker data and manual logs, tracker only contains tracker data.
+type LifetimeStatsResult struct {
+	Best     BestStatsSources     `json:"best"`
+	Lifetime LifetimeStatsSources `json:"lifetime"`
+}
+
+// Sample:
+//
+// {
+//     "total": {...},
+//     "tracker": {...}
+// }
+type BestStatsSources struct {
+	Total   BestStats `json:"total"`
+	Tracker BestStats `json:"tracker"`
+}
+
+// Sample:
+//
+// {
+//     "distance": {
+//         "date": "2019-05-18",
+//         "value": 31.21
+//     },
+//     "floors": {
+//         "date": "2020-07-04",
+//         "value": 83
+//     },
+//     "steps": {
+//         "date": "2019-05-18",
+//         "value": 41235
+//     }
+// }
+type BestStats struct {
+	Distance BestStat `json:"distance"`
+	Floors   BestStat `json:"floors"`
+	Steps    BestStat `json:"steps"`
+}
+
+// Sample:
+//
+// {
+//     "date": "2019-05-18",
+//     "value": 31.21
+// }
+type BestStat struct {
+	Date  string  `json:"date"`
+	Value float64 `json:"value"`
+}
+
+// Sample:
+//
+// {
+//     "total": {...},
+//     "tracker": {...}
+// }
+type LifetimeStatsSources struct {
+	Total   LifetimeStats `json:"total"`
+	Tracker LifetimeStats `json:"tracker"`
+}
+
+// Sample:
+//
+// {
+//     "activeScore": -1,
+//     "caloriesOut": -1,
+//     "distance": 8534.12,
+//     "floors": 9421,
+//     "steps": 11231456
+// }
+//
+// Values which are not tracked are given as -1.
+type LifetimeStats struct {
+	ActiveScore float64 `json:"activeScore"`
+	CaloriesOut float64 `json:"caloriesOut"`
+	Distance    float64 `json:"distance"`
+	Floors      float64 `json:"floors"`
+	Steps       float64 `json:"steps"`
+}
@@ -0,0 +1,241 @@
+package fitbit
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// FoodLogByDate returns the food log of the given date.
+func (c *Client) FoodLogByDate(ctx context.Context, date time.Time) (*FoodLogResult, error) {
+	path := fmt.Sprintf("/1/user/-/foods/log/date/%s.json", formatDate(date))
+	var result FoodLogResult
+	if err := c.get(ctx, path, nil, &result); err != nil {
+		return nil, fmt.Errorf("error getting food log: %w", err)
+	}
+	return &result, nil
+}
+
+// WaterLogByDate returns the water log of the given date.
+func (c *Client) WaterLogByDate(ctx context.Context, date time.Time) (*WaterLogResult, error) {
+	path := fmt.Sprintf("/1/user/-/foods/log/water/date/%s.json", formatDate(date))
+	var result WaterLogResult
+	if err := c.get(ctx, path, nil, &result); err != nil {
+		return nil, fmt.Errorf("error getting water log: %w", err)
+	}
+	return &result, nil
+}
+
+// WaterGoal returns the daily water goal of the authorized user.
+func (c *Client) WaterGoal(ctx context.Context) (*WaterGoal, error) {
+	var result WaterGoalResult
+	if err := c.get(ctx, "/1/user/-/foods/log/water/goal.json", nil, &result); err != nil {
+		return nil, fmt.Errorf("error getting water goal: %w", err)
+	}
+	return &result.Goal, nil
+}
+
+// MealType describes the meal a food log entry belongs to.
+type MealType int
+
+const (
+	MealTypeBreakfast      MealType = 1
+	MealTypeMorningSnack   MealType = 2
+	MealTypeLunch          MealType = 3
+	MealTypeAfternoonSnack MealType = 4
+	MealTypeDinner         MealType = 5
+	MealTypeAnytime        MealType = 7
+)
+
+func (m MealType) String() string {
+	switch m {
+	case MealTypeBreakfast:
+		return "breakfast"
+	case MealTypeMorningSnack:
+		return "morning_snack"
+	case MealTypeLunch:
+		return "lunch"
+	case MealTypeAfternoonSnack:
+		return "afternoon_snack"
+	case MealTypeDinner:
+		return "dinner"
+	case MealTypeAnytime:
+		return "anytime"
+	default:
+		return "other"
+	}
+}
+
+// Sample:
+//
+// {
+//     "foods": [
+//         {
+//             "isFavorite": false,
+//             "logDate": "2021-08-01",
+//             "logId": 22914315227,
+//             "loggedFood": {...},
+//             "nutritionalValues": {...}
+//         }
+//     ],
+//     "goals": {
+//         "calories": 2286
+//     },
+//     "summary": {
+//         "calories": 1752,
+//         "carbs": 231.6,
+//         "fat": 55.33,
+//         "fiber": 21.1,
+//         "protein": 78.2,
+//         "sodium": 1832,
+//         "water": 1500
+//     }
+// }
+type FoodLogResult struct {
+	Foods   []FoodLogEntry    `json:"foods"`
+	Goals   FoodGoals         `json:"goals"`
+	Summary NutritionalValues `json:"summary"`
+}
+
+// Sample:
+//
+// {
+//     "isFavorite": false,
+//     "logDate": "2021-08-01",
+//     "logId": 22914315227,
+//     "loggedFood": {
+//         "amount": 1,
+//         "brand": "",
+//         "calories": 52,
+//         "foodId": 81248,
+//         "mealTypeId": 1,
+//         "name": "Apple"
+//     },
+//     "nutritionalValues": {
+//         "calories": 52,
+//         "carbs": 13.81,
+//         "fat": 0.17,
+//         "fiber": 2.4,
+//         "protein": 0.26,
+//         "sodium": 1
+//     }
+// }
+type FoodLogEntry struct {
+	IsFavorite        bool              `json:"isFavorite"`
+	LogDate           string            `json:"logDate"`
+	LogID             int64             `json:"logId"`
+	LoggedFood        LoggedFood        `json:"loggedFood"`
+	NutritionalValues NutritionalValues `json:"nutritionalValues"`
+}
+
+// Sample:
+//
+// {
+//     "amount": 1,
+//     "brand": "",
+//     "calories": 52,
+//     "foodId": 81248,
+//     "mealTypeId": 1,
+//     "name": "Apple"
+// }
+type LoggedFood struct {
+	Amount     float64  `json:"amount"`
+	Brand      string   `json:"brand"`
+	Calories   float64  `json:"calories"`
+	FoodID     int64    `json:"foodId"`
+	MealTypeID MealType `json:"mealTypeId"`
+	Name       string   `json:"name"`
+}
+
+// Sample:
+//
+// {
+//     "calories": 1752,
+//     "carbs": 231.6,
+//     "fat": 55.33,
+//     "fiber": 21.1,
+//     "protein": 78.2,
+//     "sodium": 1832,
+//     "water": 1500
+// }
+//
+// Water is only set within the summary of a FoodLogResult.
+type NutritionalValues struct {
+	Calories float64 `json:"calories"`
+	Carbs    float64 `json:"carbs"`
+	Fat      float64 `json:"fat"`
+	Fiber    float64 `json:"fiber"`
+	Protein  float64 `json:"protein"`
+	Sodium   float64 `json:"sodium"`
+	Water    float64 `json:"water,omitempty"`
+}
+
+// Sample:
+//
+// {
+//     "calories": 2286
+// }
+type FoodGoals struct {
+	Calories float64 `json:"calories"`
+}
+
+// Sample:
+//
+// {
+//     "summary": {
+//         "water": 1500
+//     },
+//     "water": [
+//         {
+//             "amount": 500,
+//             "logId": 6234563456
+//         }
+//     ]
+// }
+type WaterLogResult struct {
+	Summary WaterSummary    `json:"summary"`
+	Water   []WaterLogEntry `json:"water"`
+}
+
+// Sample:
+//
+// {
+//     "water": 1500
+// }
+type WaterSummary struct {
+	Water float64 `json:"water"`
+}
+
+// Sample:
+//
+// {
+//     "amount": 500,
+//     "logId": 6234563456
+// }
+type WaterLogEntry struct {
+	Amount float64 `json:"amount"`
+	LogID  int64   `json:"logId"`
+}
+
+// Sample:
+//
+// {
+//     "goal": {
+//         "goal": 1893,
+//         "startDate": "2021-08-01"
+//     }
+// }
+type WaterGoalResult struct {
+	Goal WaterGoal `json:"goal"`
+}
+
+// Sample:
+//
+// {
+//     "goal": 1893,
+//     "startDate": "2021-08-01"
+// }
+type WaterGoal struct {
+	Goal      float64 `json:"goal"`
+	StartDate string  `json:"startDate"`
+}
@@ -0,0 +1,75 @@
+package fitbit
+
+import "time"
+
+// Sample:
+//
+// {
+//     "user": {
+//         "age": 34,
+//         "displayName": "Jane D.",
+//         "encodedId": "ABC123",
+//         ...
+//     }
+// }
+type ProfileResult struct {
+	User Profile `json:"user"`
+}
+
+// Sample:
+//
+// {
+//     "age": 34,
+//     "dateOfBirth": "1987-01-01",
+//     "displayName": "Jane D.",
+//     "distanceUnit": "METRIC",
+//     "encodedId": "ABC123",
+//     "fullName": "Jane Doe",
+//     "gender": "FEMALE",
+//     "height": 170.0,
+//     "heightUnit": "METRIC",
+//     "locale": "en_US",
+//     "memberSince": "2015-03-01",
+//     "offsetFromUTCMillis": 7200000,
+//     "strideLengthRunning": 105.3,
+//     "strideLengthWalking": 70.6,
+//     "timezone": "Europe/Berlin",
+//     "waterUnit": "METRIC",
+//     "weight": 62.5,
+//     "weightUnit": "METRIC"
+// }
+type Profile struct {
+	Age                 int     `json:"age"`
+	DateOfBirth         string  `json:"dateOfBirth"`
+	DisplayName         string  `json:"displayName"`
+	DistanceUnit        string  `json:"distanceUnit"`
+	EncodedID           string  `json:"encodedId"`
+	FullName            string  `json:"fullName"`
+	Gender              string  `json:"gender"`
+	Height              float64 `json:"height"`
+	HeightUnit          string  `json:"heightUnit"`
+	Locale              string  `json:"locale"`
+	MemberSince         string  `json:"memberSince"`
+	OffsetFromUTCMillis int64   `json:"offsetFromUTCMillis"`
+	StrideLengthRunning float64 `json:"strideLengthRunning"`
+	StrideLengthWalking float64 `json:"strideLengthWalking"`
+	Timezone            string  `json:"timezone"`
+	WaterUnit           string  `json:"waterUnit"`
+	Weight              float64 `json:"weight"`
+	WeightUnit          string  `json:"weightUnit"`
+}
+
+// UnitSystem returns the unit system the user has chosen for distances.
+func (p Profile) UnitSystem() UnitSystem {
+	return UnitSystem(p.DistanceUnit)
+}
+
+// Location returns the timezone of the user. Dates and timestamps returned by
+// the Fitbit API are given within this timezone. If the timezone is unknown
+// the offset from UTC of the profile is used instead.
+func (p Profile) Location() *time.Location {
+	if loc, err := time.LoadLocation(p.Timezone); err == nil && p.Timezone != "" {
+		return loc
+	}
+	return time.FixedZone(p.Timezone, int(p.OffsetFromUTCMillis/1000))
+}
@@ -0,0 +1,164 @@
+package fitbit
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// SleepByDate returns the sleep logs of the given date.
+func (c *Client) SleepByDate(ctx context.Context, date time.Time) (*SleepResult, error) {
+	path := fmt.Sprintf("/1.2/user/-/sleep/date/%s.json", formatDate(date))
+	var result SleepResult
+	if err := c.get(ctx, path, nil, &result); err != nil {
+		return nil, fmt.Errorf("error getting sleep: %w", err)
+	}
+	return &result, nil
+}
+
+// Sample:
+//
+// {
+//     "sleep": [
+//         {
+//             "dateOfSleep": "2021-08-01",
+//             "duration": 27720000,
+//             "efficiency": 96,
+//             "endTime": "2021-08-01T06:42:30.000",
+//             "isMainSleep": true,
+//             "levels": {...},
+//             "logId": 26589710670,
+//             "logType": "auto_detected",
+//             "minutesAfterWakeup": 0,
+//             "minutesAsleep": 401,
+//             "minutesAwake": 61,
+//             "minutesToFallAsleep": 0,
+//             "startTime": "2021-07-31T23:00:30.000",
+//             "timeInBed": 462,
+//             "type": "stages"
+//         }
+//     ],
+//     "summary": {
+//         "totalMinutesAsleep": 401,
+//         "totalSleepRecords": 1,
+//         "totalTimeInBed": 462
+//     }
+// }
+type SleepResult struct {
+	Sleep   []SleepLog   `json:"sleep"`
+	Summary SleepSummary `json:"summary"`
+}
+
+// MainSleep returns the main sleep log if there is any.
+func (s SleepResult) MainSleep() (SleepLog, bool) {
+	for _, sleep := range s.Sleep {
+		if sleep.IsMainSleep {
+			return sleep, true
+		}
+	}
+	return SleepLog{}, false
+}
+
+// Sample:
+//
+// {
+//     "dateOfSleep": "2021-08-01",
+//     "duration": 27720000,
+//     "efficiency": 96,
+//     "endTime": "2021-08-01T06:42:30.000",
+//     "isMainSleep": true,
+//     "levels": {...},
+//     "logId": 26589710670,
+//     "logType": "auto_detected",
+//     "minutesAfterWakeup": 0,
+//     "minutesAsleep": 401,
+//     "minutesAwake": 61,
+//     "minutesToFallAsleep": 0,
+//     "startTime": "2021-07-31T23:00:30.000",
+//     "timeInBed": 462,
+//     "type": "stages"
+// }
+type SleepLog struct {
+	DateOfSleep         string      `json:"dateOfSleep"`
+	Duration            int64       `json:"duration"`
+	Efficiency          int         `json:"efficiency"`
+	EndTime             string      `json:"endTime"`
+	IsMainSleep         bool        `json:"isMainSleep"`
+	Levels              SleepLevels `json:"levels"`
+	LogID               int64       `json:"logId"`
+	LogType             string      `json:"logType"`
+	MinutesAfterWakeup  int         `json:"minutesAfterWakeup"`
+	MinutesAsleep       int         `json:"minutesAsleep"`
+	MinutesAwake        int         `json:"minutesAwake"`
+	MinutesToFallAsleep int         `json:"minutesToFallAsleep"`
+	StartTime           string      `json:"startTime"`
+	TimeInBed           int         `json:"timeInBed"`
+	Type                string      `json:"type"`
+}
+
+// Sample:
+//
+// {
+//     "data": [
+//         {
+//             "dateTime": "2021-07-31T23:00:30.000",
+//             "level": "wake",
+//             "seconds": 630
+//         }
+//     ],
+//     "shortData": [],
+//     "summary": {
+//         "deep": {
+//             "count": 5,
+//             "minutes": 104,
+//             "thirtyDayAvgMinutes": 69
+//         }
+//     }
+// }
+//
+// The summary contains the stages deep, light, rem and wake for logs of type
+// "stages" and asleep, restless and awake for logs of type "classic".
+type SleepLevels struct {
+	Data      []SleepLevelData             `json:"data"`
+	ShortData []SleepLevelData             `json:"shortData"`
+	Summary   map[string]SleepLevelSummary `json:"summary"`
+}
+
+// Sample:
+//
+// {
+//     "dateTime": "2021-07-31T23:00:30.000",
+//     "level": "wake",
+//     "seconds": 630
+// }
+type SleepLevelData struct {
+	DateTime string `json:"dateTime"`
+	Level    string `json:"level"`
+	Seconds  int    `json:"seconds"`
+}
+
+// Sample:
+//
+// {
+//     "count": 5,
+//     "minutes": 104,
+//     "thirtyDayAvgMinutes": 69
+// }
+type SleepLevelSummary struct {
+	Count               int `json:"count"`
+	Minutes             int `json:"minutes"`
+	ThirtyDayAvgMinutes int `json:"thirtyDayAvgMinutes"`
+}
+
+// Sample:
+//
+// {
+//     "totalMinutesAsleep": 401,
+//     "totalSleepRecords": 1,
+//     "totalTimeInBed": 462
+// }
+type SleepSummary struct {
+	TotalMinutesAsleep int `json:"totalMinutesAsleep"`
+	TotalSleepRecords  int `json:"totalSleepRecords"`
+	TotalTimeInBed     int `json:"totalTimeInBed"`
+}
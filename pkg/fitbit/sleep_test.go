@@ -0,0 +1,33 @@
+package fitbit
+
+import "testing"
+
+func TestSleepResultMainSleep(t *testing.T) {
+	nap := SleepLog{LogID: 1, IsMainSleep: false, MinutesAsleep: 45}
+	night := SleepLog{LogID: 2, IsMainSleep: true, MinutesAsleep: 401}
+	tests := []struct {
+		name   string
+		sleep  []SleepLog
+		logID  int64
+		exists bool
+	}{
+		{name: "no sleep logged"},
+		{name: "only main sleep", sleep: []SleepLog{night}, logID: 2, exists: true},
+		{name: "main sleep after nap", sleep: []SleepLog{nap, night}, logID: 2, exists: true},
+		{name: "only nap", sleep: []SleepLog{nap}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := SleepResult{Sleep: test.sleep}
+
+			sleep, ok := result.MainSleep()
+
+			if ok != test.exists {
+				t.Fatalf("expected main sleep to exist to be %v, got %v", test.exists, ok)
+			}
+			if sleep.LogID != test.logID {
+				t.Errorf("expected main sleep log %d, got %d", test.logID, sleep.LogID)
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"context"
+	"crypto/subtle"
 	"embed"
 	"fmt"
 	"html/template"
@@ -9,6 +11,7 @@ import (
 	"net/url"
 
 	"github.com/mitch000001/fitbit-exporter/pkg/http/oauth"
+	"github.com/mitch000001/fitbit-exporter/pkg/scheduler"
 	"golang.org/x/oauth2"
 )
 
@@ -19,9 +22,18 @@ var temlates = template.Must(
 	template.ParseFS(templatesFS, "templates/*.html"),
 )
 
-func AuthMiddleware(config *oauth.Config, h http.Handler) http.HandlerFunc {
+// Authorizer reports whether the exporter has been authorized.
+type Authorizer interface {
+	IsAuthorized() bool
+}
+
+// stateCookieName is the name of the cookie binding the state of an
+// authorization request to the browser which started it.
+const stateCookieName = "fitbit_exporter_oauth_state"
+
+func AuthMiddleware(authorizer Authorizer, h http.Handler) http.HandlerFunc {
 	return func(rw http.ResponseWriter, r *http.Request) {
-		if !config.IsAuthorized() {
+		if !authorizer.IsAuthorized() {
 			http.Redirect(rw, r, "/auth", http.StatusTemporaryRedirect)
 			return
 		}
@@ -38,6 +50,7 @@ func Handler() http.HandlerFunc {
                 <h1>Fitbit exporter</h1>
                 <p>You successfully authorized this exporter to fetch your data from Fitbit</p>
                 <p>Visit the metrics endpoint at <a href="/metrics">/metrics</a></p>
+                <p>To enroll another Fitbit account visit <a href="/auth">/auth</a></p>
             </body>
         </html>
         `)
@@ -57,30 +70,79 @@ func AuthorizeHandler(config *oauth.Config) http.HandlerFunc {
 		if err := r.ParseForm(); err != nil {
 			http.Error(w, fmt.Sprintf("error parsing form: %v", err), http.StatusBadRequest)
 		}
-		redirectURL, err := url.Parse(r.Form.Get("redirectURL"))
+		// The redirect URL defaults to OAUTH2_REDIRECT_URL if the form does
+		// not contain the URL the browser started at.
+		if formURL := r.Form.Get("redirectURL"); formURL != "" {
+			redirectURL, err := url.Parse(formURL)
+			if err != nil {
+				log.Printf("Error parsing redirect URL: %v", err)
+			} else {
+				redirectURL.Path = "/oauth-redirect"
+				config.RedirectURL = redirectURL.String()
+			}
+		}
+		state, fitbitURL, err := config.AuthorizationURL()
 		if err != nil {
-			log.Printf("Error parsing redirect URL: %v", err)
-		} else {
-			redirectURL.Path = "/oauth-redirect"
-			config.RedirectURL = redirectURL.String()
+			log.Printf("Error issuing state: %v", err)
+			http.Error(w, "unable to start authorization", http.StatusInternalServerError)
+			return
 		}
-		fitbitURL := config.AuthCodeURL(config.State, oauth2.AccessTypeOffline)
+		http.SetCookie(w, &http.Cookie{
+			Name:     stateCookieName,
+			Value:    state,
+			Path:     "/",
+			MaxAge:   int(config.States.TTL().Seconds()),
+			Secure:   secureCookie(r, config.RedirectURL),
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
 		http.Redirect(w, r, fitbitURL, http.StatusTemporaryRedirect)
 	}
 }
 
-func OauthRedirectHandler(config *oauth.Config) http.HandlerFunc {
+// secureCookie reports whether the state cookie must only be sent via HTTPS.
+// Behind a reverse proxy terminating TLS the request itself is plain HTTP, so
+// the scheme of the redirect URL the browser returns to is decisive.
+func secureCookie(r *http.Request, redirectURL string) bool {
+	if r.TLS != nil {
+		return true
+	}
+	u, err := url.Parse(redirectURL)
+	return err == nil && u.Scheme == "https"
+}
+
+// OauthRedirectHandler completes the authorization by exchanging the
+// authorization code for a token and passing it to enroll, which enrolls the
+// user the token has been issued for.
+func OauthRedirectHandler(config *oauth.Config, enroll func(context.Context, *oauth2.Token) error) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		authCode := r.FormValue("code")
 		state := r.FormValue("state")
-		if !config.IsStateValid(state) {
+		cookie, err := r.Cookie(stateCookieName)
+		http.SetCookie(w, &http.Cookie{
+			Name:   stateCookieName,
+			Path:   "/",
+			MaxAge: -1,
+		})
+		if err != nil || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(state)) != 1 {
 			http.Error(w, "State does not match", http.StatusBadRequest)
 			return
 		}
-		if err := config.Authorize(r.Context(), authCode); err != nil {
+		codeVerifier, ok := config.VerifyState(state)
+		if !ok {
+			http.Error(w, "State is invalid or expired", http.StatusBadRequest)
+			return
+		}
+		tok, err := config.ExchangeCode(r.Context(), authCode, codeVerifier)
+		if err != nil {
 			http.Error(w, "unable to authorize oauth2 client", http.StatusInternalServerError)
 			return
 		}
+		if err := enroll(r.Context(), tok); err != nil {
+			log.Printf("Error enrolling user: %v", err)
+			http.Error(w, "unable to enroll user", http.StatusInternalServerError)
+			return
+		}
 		templateValues := map[string]interface{}{
 			"scopes": config.Scopes,
 		}
@@ -89,3 +151,13 @@ func OauthRedirectHandler(config *oauth.Config) http.HandlerFunc {
 		}
 	}
 }
+
+// StatusHandler renders the planned schedule of the scrape jobs of every
+// user. The plans are keyed by user ID.
+func StatusHandler(plans func() map[string]scheduler.Plan) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := temlates.ExecuteTemplate(w, "status.tpl.html", plans()); err != nil {
+			http.Error(w, fmt.Sprintf("error while executing template: %v", err), http.StatusInternalServerError)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/mitch000001/fitbit-exporter/pkg/http/oauth"
+	"golang.org/x/oauth2"
+)
+
+func TestAuthorizeHandlerStateCookie(t *testing.T) {
+	tests := []struct {
+		name        string
+		redirectURL string
+		formURL     string
+		secure      bool
+	}{
+		{name: "plain HTTP", redirectURL: "http://localhost:3000/oauth-redirect"},
+		{name: "behind TLS proxy", redirectURL: "https://fitbit.example.com/oauth-redirect", secure: true},
+		{name: "redirect URL of the form", redirectURL: "http://localhost:3000/oauth-redirect", formURL: "https://fitbit.example.com/auth", secure: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := &oauth.Config{
+				States: oauth.NewStateStore(oauth.DefaultStateTTL),
+				Config: &oauth2.Config{
+					ClientID:    "client-id",
+					RedirectURL: test.redirectURL,
+					Endpoint:    oauth2.Endpoint{AuthURL: "https://www.fitbit.com/oauth2/authorize"},
+				},
+			}
+			r := httptest.NewRequest(http.MethodGet, "/authorize?redirectURL="+url.QueryEscape(test.formURL), nil)
+			w := httptest.NewRecorder()
+
+			AuthorizeHandler(config)(w, r)
+
+			cookies := w.Result().Cookies()
+			if len(cookies) != 1 || cookies[0].Name != stateCookieName {
+				t.Fatalf("expected the state cookie, got %v", cookies)
+			}
+			if cookies[0].Secure != test.secure {
+				t.Errorf("expected the state cookie to be secure: %v, got %v", test.secure, cookies[0].Secure)
+			}
+		})
+	}
+}
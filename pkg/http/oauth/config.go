@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strings"
 	"sync"
 
 	"github.com/mitch000001/fitbit-exporter/pkg/http/rate"
@@ -16,29 +17,101 @@ type ClientProvider interface {
 
 type Config struct {
 	*oauth2.Config
-	State               string
+	States              *StateStore
 	RateLimiter         rate.AdjustableLimiter
+	RateLimitOptions    rate.TransportOptions
 	InstrumentTransport func(http.RoundTripper) http.RoundTripper
 	tokenCache          TokenCache
 	tokenSource         oauth2.TokenSource
 	mutex               sync.Mutex
 }
 
-func (o *Config) Authorize(ctx context.Context, authCode string) error {
-	tok, err := o.Exchange(ctx, authCode, oauth2.AccessTypeOffline)
+// AuthorizationURL starts a new authorization request. It returns the state
+// of the request and the URL to redirect the user to. The URL carries the PKCE
+// code challenge of the request.
+func (o *Config) AuthorizationURL() (string, string, error) {
+	if o.States == nil {
+		return "", "", fmt.Errorf("no state store configured")
+	}
+	verifier, err := newCodeVerifier()
+	if err != nil {
+		return "", "", err
+	}
+	state, err := o.States.Issue(verifier)
 	if err != nil {
-		return fmt.Errorf("error exchanging token: %v", err)
+		return "", "", err
 	}
-	o.tokenSource = o.TokenSource(ctx, tok)
+	opts := append([]oauth2.AuthCodeOption{oauth2.AccessTypeOffline}, codeChallengeOptions(verifier)...)
+	return state, o.AuthCodeURL(state, opts...), nil
+}
+
+// Authorize exchanges the authorization code for a token and authorizes the
+// config with it. The code verifier is the one returned by VerifyState.
+func (o *Config) Authorize(ctx context.Context, authCode, codeVerifier string) error {
+	tok, err := o.ExchangeCode(ctx, authCode, codeVerifier)
+	if err != nil {
+		return err
+	}
+	return o.SetToken(tok)
+}
+
+// ExchangeCode exchanges the authorization code for a token without
+// authorizing the config itself. This allows to enroll the token of another
+// user. The code verifier is sent along if not empty.
+func (o *Config) ExchangeCode(ctx context.Context, authCode, codeVerifier string) (*oauth2.Token, error) {
+	opts := []oauth2.AuthCodeOption{oauth2.AccessTypeOffline}
+	if codeVerifier != "" {
+		opts = append(opts, codeVerifierOption(codeVerifier))
+	}
+	tok, err := o.Exchange(ctx, authCode, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("error exchanging token: %v", err)
+	}
+	return tok, nil
+}
+
+// SetToken authorizes the config with the token and writes it to the token
+// cache if set.
+func (o *Config) SetToken(tok *oauth2.Token) error {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
 	if o.tokenCache == nil {
+		o.tokenSource = o.TokenSource(context.Background(), tok)
 		return nil
 	}
+	// The replaced token source must not overwrite tok with a token it is
+	// still refreshing.
+	o.detachTokenSource()
 	if err := o.tokenCache.Refresh(tok); err != nil {
 		return fmt.Errorf("error refreshing token cache: %w", err)
 	}
+	o.tokenSource = NewPersistingTokenSource(o.TokenSource(context.Background(), tok), o.tokenCache, tok)
 	return nil
 }
 
+// detachTokenSource stops the token source from writing refreshed tokens to
+// the token cache. It needs to be called with the mutex held.
+func (o *Config) detachTokenSource() {
+	if previous, ok := o.tokenSource.(*persistingTokenSource); ok {
+		previous.detach()
+	}
+}
+
+// currentTokenSource returns the token source of the config or nil if not yet
+// authorized.
+func (o *Config) currentTokenSource() oauth2.TokenSource {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	return o.tokenSource
+}
+
+// UserID returns the ID of the Fitbit user the token has been issued for, or
+// an empty string if the token response did not contain it.
+func UserID(tok *oauth2.Token) string {
+	userID, _ := tok.Extra("user_id").(string)
+	return userID
+}
+
 func (o *Config) IsAuthorized() bool {
 	tok, err := o.Token()
 	if err != nil {
@@ -47,29 +120,60 @@ func (o *Config) IsAuthorized() bool {
 	return tok.Valid()
 }
 
-func (o *Config) IsStateValid(state string) bool {
-	return o.State == state
+// HasScope reports whether the scope is granted. The scopes granted are taken
+// from the token response if available and from the requested scopes otherwise.
+func (o *Config) HasScope(scope string) bool {
+	scopes := o.Scopes
+	if tok, err := o.Token(); err == nil {
+		if granted, ok := tok.Extra("scope").(string); ok && granted != "" {
+			scopes = strings.Fields(granted)
+		}
+	}
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// VerifyState reports whether the state has been issued for an authorization
+// request which has not yet expired or been completed, and returns the PKCE
+// code verifier of the request. The state is invalidated by the check.
+func (o *Config) VerifyState(state string) (string, bool) {
+	if o.States == nil {
+		return "", false
+	}
+	return o.States.Consume(state)
 }
 
+// Token returns a valid token, refreshing it if necessary. It is safe to call
+// while the token is replaced with SetToken.
 func (o *Config) Token() (*oauth2.Token, error) {
-	if o.tokenSource == nil {
+	tokenSource := o.currentTokenSource()
+	if tokenSource == nil {
 		return nil, fmt.Errorf("client not yet authorized")
 	}
-	return o.tokenSource.Token()
+	return tokenSource.Token()
 }
 
 func (o *Config) Client(ctx context.Context) (*http.Client, error) {
-	o.mutex.Lock()
-	defer o.mutex.Unlock()
-	tok, err := o.Token()
+	tokenSource := o.currentTokenSource()
+	if tokenSource == nil {
+		return nil, fmt.Errorf("error getting token: client not yet authorized")
+	}
+	tok, err := tokenSource.Token()
 	if err != nil {
 		return nil, fmt.Errorf("error getting token: %w", err)
 	}
-	client := o.Config.Client(ctx, tok)
+	// The client refreshes the token with the token source of the config, so
+	// refreshed tokens are written to the token cache.
+	client := oauth2.NewClient(ctx, oauth2.ReuseTokenSource(tok, tokenSource))
 	if o.RateLimiter != nil {
-		transport := rate.NewTransport(
+		transport := rate.NewTransportWithOptions(
 			o.RateLimiter,
 			client.Transport,
+			o.RateLimitOptions,
 		)
 		client.Transport = transport
 	}
@@ -87,6 +191,7 @@ func (o *Config) SetTokenCache(cache TokenCache) error {
 	if err != nil {
 		return fmt.Errorf("error getting token from cache: %w", err)
 	}
-	o.tokenSource = o.TokenSource(context.Background(), tok)
+	o.detachTokenSource()
+	o.tokenSource = NewPersistingTokenSource(o.TokenSource(context.Background(), tok), o.tokenCache, tok)
 	return nil
 }
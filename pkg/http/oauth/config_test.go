@@ -0,0 +1,114 @@
+package oauth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+func TestConfigSetTokenConcurrently(t *testing.T) {
+	tokenServer := &rotatingTokenServer{refreshToken: "refresh-0", expiresIn: 3600}
+	server := httptest.NewServer(tokenServer)
+	defer server.Close()
+	cache, err := NewJSONFileTokenCacheFromToken(filepath.Join(t.TempDir(), "token.json"), expiredToken("refresh-0"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	conf := newTestConfig(server.URL)
+	if err := conf.SetTokenCache(cache); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				conf.HasScope("heartrate")
+				conf.IsAuthorized()
+				if _, err := conf.Client(context.Background()); err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			}
+		}()
+	}
+	for i := 0; i < 20; i++ {
+		tok := &oauth2.Token{
+			AccessToken:  fmt.Sprintf("enrolled-%d", i),
+			RefreshToken: "refresh-0",
+			Expiry:       time.Now().Add(time.Hour),
+		}
+		if err := conf.SetToken(tok); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	wg.Wait()
+}
+
+// blockingTokenServer answers a refresh once it is released.
+type blockingTokenServer struct {
+	received chan struct{}
+	release  chan struct{}
+}
+
+func (s *blockingTokenServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	close(s.received)
+	<-s.release
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprint(w, `{"access_token":"stale","refresh_token":"stale","token_type":"Bearer","expires_in":3600}`)
+}
+
+func TestConfigSetTokenDuringRefresh(t *testing.T) {
+	tokenServer := &blockingTokenServer{received: make(chan struct{}), release: make(chan struct{})}
+	server := httptest.NewServer(tokenServer)
+	defer server.Close()
+	filePath := filepath.Join(t.TempDir(), "token.json")
+	cache, err := NewJSONFileTokenCacheFromToken(filePath, expiredToken("refresh-0"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	conf := newTestConfig(server.URL)
+	if err := conf.SetTokenCache(cache); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	refreshed := make(chan struct{})
+	go func() {
+		defer close(refreshed)
+		conf.Token()
+	}()
+	<-tokenServer.received
+	enrolled := &oauth2.Token{AccessToken: "enrolled", RefreshToken: "enrolled", Expiry: time.Now().Add(time.Hour)}
+	if err := conf.SetToken(enrolled); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	close(tokenServer.release)
+	<-refreshed
+
+	cache, err = NewJSONFileTokenCache(filePath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cached, err := cache.Token()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cached.RefreshToken != "enrolled" {
+		t.Errorf("expected the enrolled token to be cached, got refresh token %q", cached.RefreshToken)
+	}
+	tok, err := conf.Token()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tok.AccessToken != "enrolled" {
+		t.Errorf("expected the enrolled token, got access token %q", tok.AccessToken)
+	}
+}
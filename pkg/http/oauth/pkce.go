@@ -0,0 +1,47 @@
+package oauth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+
+	"golang.org/x/oauth2"
+)
+
+// codeChallengeMethod is the PKCE code challenge method, as defined in RFC 7636.
+const codeChallengeMethod = "S256"
+
+// randomString returns n random bytes encoded as unpadded base64url string.
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// newCodeVerifier returns a random PKCE code verifier of 43 characters.
+func newCodeVerifier() (string, error) {
+	verifier, err := randomString(32)
+	if err != nil {
+		return "", fmt.Errorf("error generating code verifier: %w", err)
+	}
+	return verifier, nil
+}
+
+// codeChallengeOptions returns the options to send the S256 code challenge of
+// the verifier with the authorization request.
+func codeChallengeOptions(verifier string) []oauth2.AuthCodeOption {
+	challenge := sha256.Sum256([]byte(verifier))
+	return []oauth2.AuthCodeOption{
+		oauth2.SetAuthURLParam("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:])),
+		oauth2.SetAuthURLParam("code_challenge_method", codeChallengeMethod),
+	}
+}
+
+// codeVerifierOption returns the option to send the code verifier with the
+// token request.
+func codeVerifierOption(verifier string) oauth2.AuthCodeOption {
+	return oauth2.SetAuthURLParam("code_verifier", verifier)
+}
@@ -0,0 +1,142 @@
+package oauth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+// authURLQuery returns the query of the authorization URL built with the
+// options.
+func authURLQuery(t *testing.T, opts ...oauth2.AuthCodeOption) url.Values {
+	t.Helper()
+	conf := &oauth2.Config{
+		ClientID: "client-id",
+		Endpoint: oauth2.Endpoint{AuthURL: "https://www.fitbit.com/oauth2/authorize"},
+	}
+	authURL, err := url.Parse(conf.AuthCodeURL("state", opts...))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return authURL.Query()
+}
+
+func TestCodeChallenge(t *testing.T) {
+	// The example of RFC 7636, Appendix B.
+	query := authURLQuery(t, codeChallengeOptions("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk")...)
+
+	if challenge := query.Get("code_challenge"); challenge != "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM" {
+		t.Errorf("expected code challenge %q, got %q", "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM", challenge)
+	}
+	if method := query.Get("code_challenge_method"); method != "S256" {
+		t.Errorf("expected code challenge method %q, got %q", "S256", method)
+	}
+}
+
+func TestNewCodeVerifier(t *testing.T) {
+	// RFC 7636 allows 43 to 128 unreserved characters.
+	unreserved := regexp.MustCompile(`^[A-Za-z0-9\-._~]{43,128}$`)
+	verifiers := make(map[string]bool)
+	for i := 0; i < 10; i++ {
+		verifier, err := newCodeVerifier()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !unreserved.MatchString(verifier) {
+			t.Errorf("expected a verifier of 43 to 128 unreserved characters, got %q", verifier)
+		}
+		verifiers[verifier] = true
+	}
+	if len(verifiers) != 10 {
+		t.Errorf("expected 10 distinct verifiers, got %d", len(verifiers))
+	}
+}
+
+func TestConfigAuthorizationCodeFlowWithPKCE(t *testing.T) {
+	var tokenRequest url.Values
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		tokenRequest = r.PostForm
+		authorization = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token":"access","refresh_token":"refresh","token_type":"Bearer","expires_in":28800,"user_id":"ABC123"}`)
+	}))
+	defer server.Close()
+	// Apps of type client have no secret and send their client ID within the
+	// token request.
+	conf := &Config{
+		States: NewStateStore(DefaultStateTTL),
+		Config: &oauth2.Config{
+			ClientID:    "client-id",
+			RedirectURL: "http://localhost:3000/oauth-redirect",
+			Endpoint: oauth2.Endpoint{
+				AuthURL:   "https://www.fitbit.com/oauth2/authorize",
+				TokenURL:  server.URL,
+				AuthStyle: oauth2.AuthStyleInParams,
+			},
+		},
+	}
+
+	state, authCodeURL, err := conf.AuthorizationURL()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	authURL, err := url.Parse(authCodeURL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	query := authURL.Query()
+	if query.Get("state") != state {
+		t.Errorf("expected state %q, got %q", state, query.Get("state"))
+	}
+	if method := query.Get("code_challenge_method"); method != "S256" {
+		t.Errorf("expected code challenge method %q, got %q", "S256", method)
+	}
+	verifier, ok := conf.VerifyState(state)
+	if !ok {
+		t.Fatalf("expected state %q to be valid", state)
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	if challenge := base64.RawURLEncoding.EncodeToString(sum[:]); query.Get("code_challenge") != challenge {
+		t.Errorf("expected code challenge %q of the stored verifier, got %q", challenge, query.Get("code_challenge"))
+	}
+	if _, ok := conf.VerifyState(state); ok {
+		t.Errorf("expected the verifier to be dropped after use")
+	}
+
+	tok, err := conf.ExchangeCode(context.Background(), "auth-code", verifier)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if UserID(tok) != "ABC123" {
+		t.Errorf("expected token of user %q, got %q", "ABC123", UserID(tok))
+	}
+	expected := map[string]string{
+		"grant_type":    "authorization_code",
+		"code":          "auth-code",
+		"code_verifier": verifier,
+		"client_id":     "client-id",
+		"redirect_uri":  "http://localhost:3000/oauth-redirect",
+	}
+	for key, value := range expected {
+		if tokenRequest.Get(key) != value {
+			t.Errorf("expected %s %q within the token request, got %q", key, value, tokenRequest.Get(key))
+		}
+	}
+	if tokenRequest.Get("client_secret") != "" || authorization != "" {
+		t.Errorf("expected no client secret to be sent, got %q and authorization %q", tokenRequest.Get("client_secret"), authorization)
+	}
+}
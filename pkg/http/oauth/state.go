@@ -0,0 +1,101 @@
+package oauth
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultStateTTL is the time an authorization request has to be completed in.
+const DefaultStateTTL = 5 * time.Minute
+
+// MaxPendingStates is the maximum number of authorization requests pending at
+// the same time. As anyone can start an authorization request, the oldest
+// request is dropped once the limit is reached to bound the memory used.
+const MaxPendingStates = 1000
+
+// StateStore issues a fresh random state for every authorization request.
+// A state expires after the TTL and is invalidated on first use. The PKCE code
+// verifier of the request is kept along with the state.
+type StateStore struct {
+	ttl       time.Duration
+	maxStates int
+	now       func() time.Time
+	mutex     sync.Mutex
+	states    map[string]authRequest
+}
+
+type authRequest struct {
+	expiresAt    time.Time
+	codeVerifier string
+}
+
+func NewStateStore(ttl time.Duration) *StateStore {
+	return &StateStore{
+		ttl:       ttl,
+		maxStates: MaxPendingStates,
+		now:       time.Now,
+		states:    make(map[string]authRequest),
+	}
+}
+
+// TTL returns the time after which an issued state expires.
+func (s *StateStore) TTL() time.Duration {
+	return s.ttl
+}
+
+// Issue returns a new random state for an authorization request with the
+// code verifier.
+func (s *StateStore) Issue(codeVerifier string) (string, error) {
+	state, err := randomString(32)
+	if err != nil {
+		return "", fmt.Errorf("error generating state: %w", err)
+	}
+	now := s.now()
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for issued, request := range s.states {
+		if !now.Before(request.expiresAt) {
+			delete(s.states, issued)
+		}
+	}
+	if len(s.states) >= s.maxStates {
+		s.dropOldest()
+	}
+	s.states[state] = authRequest{
+		expiresAt:    now.Add(s.ttl),
+		codeVerifier: codeVerifier,
+	}
+	return state, nil
+}
+
+// dropOldest removes the state expiring first. It needs to be called with the
+// mutex held.
+func (s *StateStore) dropOldest() {
+	var oldest string
+	var oldestExpiresAt time.Time
+	for issued, request := range s.states {
+		if oldest == "" || request.expiresAt.Before(oldestExpiresAt) {
+			oldest = issued
+			oldestExpiresAt = request.expiresAt
+		}
+	}
+	delete(s.states, oldest)
+}
+
+// Consume reports whether the state has been issued and is not yet expired
+// and returns the code verifier of the request. The state is invalidated, so
+// it can be consumed only once.
+func (s *StateStore) Consume(state string) (string, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	request, ok := s.states[state]
+	if !ok {
+		return "", false
+	}
+	delete(s.states, state)
+	if !s.now().Before(request.expiresAt) {
+		return "", false
+	}
+	return request.codeVerifier, true
+}
@@ -0,0 +1,100 @@
+package oauth
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestStateStore(now *time.Time) *StateStore {
+	s := NewStateStore(DefaultStateTTL)
+	s.now = func() time.Time { return *now }
+	return s
+}
+
+func TestStateStoreConsumesOnce(t *testing.T) {
+	now := time.Date(2021, 8, 1, 13, 0, 0, 0, time.UTC)
+	s := newTestStateStore(&now)
+	state, err := s.Issue("verifier")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	verifier, ok := s.Consume(state)
+	if !ok || verifier != "verifier" {
+		t.Fatalf("expected the state to be valid with code verifier %q, got %q, %v", "verifier", verifier, ok)
+	}
+	if _, ok := s.Consume(state); ok {
+		t.Errorf("expected the state to be consumed only once")
+	}
+	if _, ok := s.Consume("unknown"); ok {
+		t.Errorf("expected an unknown state to be invalid")
+	}
+}
+
+func TestStateStoreExpiry(t *testing.T) {
+	now := time.Date(2021, 8, 1, 13, 0, 0, 0, time.UTC)
+	s := newTestStateStore(&now)
+	expired, err := s.Issue("expired")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	now = now.Add(DefaultStateTTL - time.Second)
+	valid, err := s.Issue("valid")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	now = now.Add(time.Second)
+	if _, ok := s.Consume(expired); ok {
+		t.Errorf("expected the state to be expired after %v", DefaultStateTTL)
+	}
+	if _, ok := s.Consume(valid); !ok {
+		t.Errorf("expected the state to be valid before %v", DefaultStateTTL)
+	}
+}
+
+func TestStateStoreBoundsPendingStates(t *testing.T) {
+	now := time.Date(2021, 8, 1, 13, 0, 0, 0, time.UTC)
+	s := newTestStateStore(&now)
+	s.maxStates = 3
+	var states []string
+	for i := 0; i < 3; i++ {
+		state, err := s.Issue("verifier")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		states = append(states, state)
+		now = now.Add(time.Second)
+	}
+
+	latest, err := s.Issue("latest")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(s.states) != 3 {
+		t.Errorf("expected at most 3 pending states, got %d", len(s.states))
+	}
+	if _, ok := s.Consume(states[0]); ok {
+		t.Errorf("expected the oldest state to be dropped")
+	}
+	for _, state := range append(states[1:], latest) {
+		if _, ok := s.Consume(state); !ok {
+			t.Errorf("expected state %q to be valid", state)
+		}
+	}
+
+	// Expired states are purged before dropping any pending one.
+	for i := 0; i < 3; i++ {
+		if _, err := s.Issue("expired"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	now = now.Add(DefaultStateTTL)
+	if _, err := s.Issue("verifier"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(s.states) != 1 {
+		t.Errorf("expected expired states to be purged, got %d pending states", len(s.states))
+	}
+}
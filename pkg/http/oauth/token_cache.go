@@ -2,13 +2,20 @@ package oauth
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"runtime"
 	"sync"
 
+	"github.com/mitch000001/fitbit-exporter/pkg/atomicfile"
 	"golang.org/x/oauth2"
 )
 
+// ErrInsecureTokenFile is returned if a token file can be accessed by users
+// other than its owner.
+var ErrInsecureTokenFile = errors.New("token file permissions are too open")
+
 type TokenCache interface {
 	oauth2.TokenSource
 	Refresh(*oauth2.Token) error
@@ -24,6 +31,9 @@ func NewJSONFileTokenCacheFromToken(filePath string, tok *oauth2.Token) (TokenCa
 	return tokenCache, nil
 }
 
+// NewJSONFileTokenCache returns a TokenCache keeping the token in the JSON
+// file. It returns ErrInsecureTokenFile if the file is accessible by others
+// than its owner.
 func NewJSONFileTokenCache(filePath string) (TokenCache, error) {
 	cache := &jsonFileTokenCache{
 		filePath: filePath,
@@ -34,6 +44,9 @@ func NewJSONFileTokenCache(filePath string) (TokenCache, error) {
 	return cache, nil
 }
 
+// jsonFileTokenCache writes the token atomically, so a crash never leaves a
+// truncated token file behind. The previously written token is kept in a
+// backup file next to it.
 type jsonFileTokenCache struct {
 	filePath string
 	token    *oauth2.Token
@@ -49,29 +62,48 @@ func (t *jsonFileTokenCache) Token() (*oauth2.Token, error) {
 func (t *jsonFileTokenCache) Refresh(tok *oauth2.Token) error {
 	t.mutex.Lock()
 	defer t.mutex.Unlock()
-	file, err := os.Create(t.filePath)
+	data, err := json.MarshalIndent(tok, "", "  ")
 	if err != nil {
-		return fmt.Errorf("error creating token file: %w", err)
-	}
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(tok); err != nil {
 		return fmt.Errorf("error marshaling json token: %w", err)
 	}
+	previous, err := os.ReadFile(t.filePath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error reading token file: %w", err)
+	}
+	if err == nil {
+		if err := atomicfile.WriteFile(t.backupPath(), previous); err != nil {
+			return fmt.Errorf("error writing token backup file: %w", err)
+		}
+	}
+	if err := atomicfile.WriteFile(t.filePath, append(data, '\n')); err != nil {
+		return fmt.Errorf("error writing token file: %w", err)
+	}
 	t.token = tok
 	return nil
 }
 
+func (t *jsonFileTokenCache) backupPath() string {
+	return t.filePath + ".bak"
+}
+
 func (t *jsonFileTokenCache) load() error {
 	t.mutex.Lock()
 	defer t.mutex.Unlock()
-	if _, err := os.Stat(t.filePath); os.IsNotExist(err) {
+	info, err := os.Stat(t.filePath)
+	if os.IsNotExist(err) {
 		return nil
 	}
+	if err != nil {
+		return fmt.Errorf("error reading token file: %w", err)
+	}
+	if err := checkPermissions(info); err != nil {
+		return fmt.Errorf("%s: %w", t.filePath, err)
+	}
 	file, err := os.Open(t.filePath)
 	if err != nil {
 		return fmt.Errorf("error reading token file: %w", err)
 	}
+	defer file.Close()
 	var tok oauth2.Token
 	if err := json.NewDecoder(file).Decode(&tok); err != nil {
 		return fmt.Errorf("error unmarshaling json token: %w", err)
@@ -79,3 +111,15 @@ func (t *jsonFileTokenCache) load() error {
 	t.token = &tok
 	return nil
 }
+
+// checkPermissions returns ErrInsecureTokenFile if the file can be accessed
+// by its group or others. Windows has no such permission bits.
+func checkPermissions(info os.FileInfo) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	if perm := info.Mode().Perm(); perm&0077 != 0 {
+		return fmt.Errorf("%w: mode is %#o, run chmod 600 to fix it", ErrInsecureTokenFile, perm)
+	}
+	return nil
+}
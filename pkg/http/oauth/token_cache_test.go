@@ -0,0 +1,88 @@
+package oauth
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+func TestJSONFileTokenCacheRefresh(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "token.json")
+
+	cache, err := NewJSONFileTokenCacheFromToken(filePath, &oauth2.Token{RefreshToken: "refresh-0"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(filePath + ".bak"); !os.IsNotExist(err) {
+		t.Errorf("expected no backup file for the first token, got %v", err)
+	}
+	if err := cache.Refresh(&oauth2.Token{RefreshToken: "refresh-1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if runtime.GOOS != "windows" {
+		for _, path := range []string{filePath, filePath + ".bak"} {
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if perm := info.Mode().Perm(); perm != 0600 {
+				t.Errorf("expected %s to have mode 0600, got %#o", path, perm)
+			}
+		}
+	}
+	for path, expected := range map[string]string{filePath: "refresh-1", filePath + ".bak": "refresh-0"} {
+		cache, err := NewJSONFileTokenCache(path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		tok, err := cache.Token()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if tok.RefreshToken != expected {
+			t.Errorf("expected %s to contain refresh token %q, got %q", path, expected, tok.RefreshToken)
+		}
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 2 {
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		t.Errorf("expected only the token and backup file, got %v", names)
+	}
+}
+
+func TestJSONFileTokenCacheRejectsOpenPermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permission bits are not supported on windows")
+	}
+	filePath := filepath.Join(t.TempDir(), "token.json")
+	if err := os.WriteFile(filePath, []byte(`{"refresh_token":"refresh-0"}`), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := os.Chmod(filePath, 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err := NewJSONFileTokenCache(filePath)
+	if !errors.Is(err, ErrInsecureTokenFile) {
+		t.Fatalf("expected %v, got %v", ErrInsecureTokenFile, err)
+	}
+
+	if err := os.Chmod(filePath, 0600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := NewJSONFileTokenCache(filePath); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+package oauth
+
+import (
+	"log"
+	"sync"
+
+	"golang.org/x/oauth2"
+)
+
+// NewPersistingTokenSource returns a TokenSource writing every token returned
+// by source back to the cache if it differs from the last one. As Fitbit
+// rotates the refresh token on every refresh, this keeps the cached token
+// usable after a restart. tok is the token currently in the cache.
+func NewPersistingTokenSource(source oauth2.TokenSource, cache TokenCache, tok *oauth2.Token) oauth2.TokenSource {
+	return &persistingTokenSource{
+		source: source,
+		cache:  cache,
+		last:   tok,
+	}
+}
+
+type persistingTokenSource struct {
+	source oauth2.TokenSource
+	cache  TokenCache
+	// mutex serializes fetching and persisting tokens, so a refreshed token
+	// is written before anyone else can observe it.
+	mutex sync.Mutex
+	last  *oauth2.Token
+	// detachMutex guards writing to the cache, so no token is written once
+	// detach returns, without waiting for a refresh in flight.
+	detachMutex sync.Mutex
+	detached    bool
+}
+
+// detach stops writing tokens to the cache, as the token source is replaced
+// by another one.
+func (s *persistingTokenSource) detach() {
+	s.detachMutex.Lock()
+	defer s.detachMutex.Unlock()
+	s.detached = true
+}
+
+func (s *persistingTokenSource) Token() (*oauth2.Token, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	tok, err := s.source.Token()
+	if err != nil {
+		return nil, err
+	}
+	if !tokenChanged(s.last, tok) {
+		return tok, nil
+	}
+	if err := s.persist(tok); err != nil {
+		// The token is still valid, so it is returned anyway. Writing it is
+		// retried with the next call.
+		log.Printf("Error persisting refreshed token: %v", err)
+		return tok, nil
+	}
+	s.last = tok
+	return tok, nil
+}
+
+func (s *persistingTokenSource) persist(tok *oauth2.Token) error {
+	s.detachMutex.Lock()
+	defer s.detachMutex.Unlock()
+	if s.detached {
+		return nil
+	}
+	return s.cache.Refresh(tok)
+}
+
+func tokenChanged(last, tok *oauth2.Token) bool {
+	if last == nil {
+		return true
+	}
+	return last.AccessToken != tok.AccessToken ||
+		last.RefreshToken != tok.RefreshToken ||
+		!last.Expiry.Equal(tok.Expiry)
+}
@@ -0,0 +1,194 @@
+package oauth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// rotatingTokenServer is a token endpoint which rotates the refresh token on
+// every refresh and rejects all refresh tokens but the latest, like Fitbit.
+type rotatingTokenServer struct {
+	mutex        sync.Mutex
+	refreshToken string
+	refreshes    int
+	expiresIn    int
+}
+
+func (s *rotatingTokenServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	if r.Form.Get("grant_type") != "refresh_token" || r.Form.Get("refresh_token") != s.refreshToken {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"errors":[{"errorType":"invalid_grant","message":"Refresh token invalid"}]}`)
+		return
+	}
+	s.refreshes++
+	s.refreshToken = fmt.Sprintf("refresh-%d", s.refreshes)
+	fmt.Fprintf(w, `{"access_token":"access-%d","refresh_token":%q,"token_type":"Bearer","expires_in":%d}`, s.refreshes, s.refreshToken, s.expiresIn)
+}
+
+func newTestConfig(tokenURL string) *Config {
+	return &Config{
+		Config: &oauth2.Config{
+			ClientID:     "client-id",
+			ClientSecret: "client-secret",
+			Endpoint: oauth2.Endpoint{
+				TokenURL:  tokenURL,
+				AuthStyle: oauth2.AuthStyleInHeader,
+			},
+		},
+	}
+}
+
+func expiredToken(refreshToken string) *oauth2.Token {
+	return &oauth2.Token{
+		AccessToken:  "expired",
+		TokenType:    "Bearer",
+		RefreshToken: refreshToken,
+		Expiry:       time.Now().Add(-time.Hour),
+	}
+}
+
+func TestConfigPersistsRefreshedTokenAcrossRestart(t *testing.T) {
+	// Tokens expiring within seconds are refreshed on every use.
+	tokenServer := &rotatingTokenServer{refreshToken: "refresh-0", expiresIn: 1}
+	server := httptest.NewServer(tokenServer)
+	defer server.Close()
+	filePath := filepath.Join(t.TempDir(), "token.json")
+
+	cache, err := NewJSONFileTokenCacheFromToken(filePath, expiredToken("refresh-0"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	conf := newTestConfig(server.URL)
+	if err := conf.SetTokenCache(cache); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := 1; i <= 2; i++ {
+		tok, err := conf.Token()
+		if err != nil {
+			t.Fatalf("unexpected error refreshing token %d: %v", i, err)
+		}
+		if expected := fmt.Sprintf("access-%d", i); tok.AccessToken != expected {
+			t.Errorf("expected access token %q, got %q", expected, tok.AccessToken)
+		}
+	}
+
+	// Restart with a new cache and config reading the same file.
+	cache, err = NewJSONFileTokenCache(filePath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cached, err := cache.Token()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cached.RefreshToken != "refresh-2" {
+		t.Fatalf("expected cached refresh token %q, got %q", "refresh-2", cached.RefreshToken)
+	}
+	conf = newTestConfig(server.URL)
+	if err := conf.SetTokenCache(cache); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tok, err := conf.Token()
+	if err != nil {
+		t.Fatalf("unexpected error refreshing token after restart: %v", err)
+	}
+	if tok.AccessToken != "access-3" {
+		t.Errorf("expected access token %q, got %q", "access-3", tok.AccessToken)
+	}
+}
+
+func TestConfigClientPersistsRefreshedToken(t *testing.T) {
+	tokenServer := &rotatingTokenServer{refreshToken: "refresh-0", expiresIn: 1}
+	server := httptest.NewServer(tokenServer)
+	defer server.Close()
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.Header.Get("Authorization"))
+	}))
+	defer api.Close()
+	filePath := filepath.Join(t.TempDir(), "token.json")
+
+	cache, err := NewJSONFileTokenCacheFromToken(filePath, expiredToken("refresh-0"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	conf := newTestConfig(server.URL)
+	if err := conf.SetTokenCache(cache); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	client, err := conf.Client(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The token expires immediately, so every request refreshes it.
+	for i := 0; i < 3; i++ {
+		response, err := client.Get(api.URL)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		response.Body.Close()
+	}
+
+	cache, err = NewJSONFileTokenCache(filePath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cached, err := cache.Token()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tokenServer.mutex.Lock()
+	latest := tokenServer.refreshToken
+	tokenServer.mutex.Unlock()
+	if cached.RefreshToken != latest {
+		t.Errorf("expected cached refresh token %q, got %q", latest, cached.RefreshToken)
+	}
+}
+
+type countingTokenCache struct {
+	TokenCache
+	refreshes int
+}
+
+func (c *countingTokenCache) Refresh(tok *oauth2.Token) error {
+	c.refreshes++
+	return c.TokenCache.Refresh(tok)
+}
+
+func TestPersistingTokenSourceOnlyWritesChangedTokens(t *testing.T) {
+	tokenServer := &rotatingTokenServer{refreshToken: "refresh-0", expiresIn: 3600}
+	server := httptest.NewServer(tokenServer)
+	defer server.Close()
+	cache, err := NewJSONFileTokenCacheFromToken(filepath.Join(t.TempDir(), "token.json"), expiredToken("refresh-0"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	counting := &countingTokenCache{TokenCache: cache}
+	conf := newTestConfig(server.URL)
+	if err := conf.SetTokenCache(counting); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		if _, err := conf.Token(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if counting.refreshes != 1 {
+		t.Errorf("expected the refreshed token to be written once, got %d writes", counting.refreshes)
+	}
+}
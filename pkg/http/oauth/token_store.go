@@ -0,0 +1,54 @@
+package oauth
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// TokenStore holds a token cache for every enrolled user.
+type TokenStore interface {
+	// UserIDs returns the IDs of all users with a cached token.
+	UserIDs() ([]string, error)
+	// TokenCache returns the token cache of the user.
+	TokenCache(userID string) (TokenCache, error)
+}
+
+// NewJSONFileTokenStore returns a TokenStore keeping the token of every user
+// in its own JSON file within dir. The directory is created if it does not
+// exist.
+func NewJSONFileTokenStore(dir string) (TokenStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("error creating token directory: %w", err)
+	}
+	return &jsonFileTokenStore{
+		dir: dir,
+	}, nil
+}
+
+type jsonFileTokenStore struct {
+	dir string
+}
+
+func (s *jsonFileTokenStore) UserIDs() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading token directory: %w", err)
+	}
+	var userIDs []string
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		userIDs = append(userIDs, strings.TrimSuffix(entry.Name(), ".json"))
+	}
+	return userIDs, nil
+}
+
+func (s *jsonFileTokenStore) TokenCache(userID string) (TokenCache, error) {
+	if userID == "" || userID != filepath.Base(userID) || strings.HasPrefix(userID, ".") {
+		return nil, fmt.Errorf("invalid user ID %q", userID)
+	}
+	return NewJSONFileTokenCache(filepath.Join(s.dir, userID+".json"))
+}
@@ -11,6 +11,38 @@ import (
 	"golang.org/x/time/rate"
 )
 
+// Clock provides the current time and timers. It allows to replace the
+// system clock within tests.
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer fires once on its channel after its duration. It needs to be stopped
+// if not fired to release its resources.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+type realTimer struct {
+	*time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time {
+	return t.Timer.C
+}
+
 type Limiter interface {
 	Wait(context.Context) error
 }
@@ -18,22 +50,52 @@ type Limiter interface {
 type AdjustableLimiter interface {
 	Limiter
 	AdjustLimit(http.Header) error
+	// Pause blocks all callers of Wait for the given duration.
+	Pause(time.Duration)
 }
 
 func NonAdjustable(rl Limiter) AdjustableLimiter {
 	return &nonadjustableLimiter{
 		Limiter: rl,
+		clock:   realClock{},
 	}
 }
 
 type nonadjustableLimiter struct {
 	Limiter
+	clock       Clock
+	mutex       sync.Mutex
+	pausedUntil time.Time
+}
+
+func (rl *nonadjustableLimiter) Wait(ctx context.Context) error {
+	rl.mutex.Lock()
+	wait := rl.pausedUntil.Sub(rl.clock.Now())
+	rl.mutex.Unlock()
+	if wait > 0 {
+		timer := rl.clock.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C():
+		}
+	}
+	return rl.Limiter.Wait(ctx)
 }
 
 func (rl *nonadjustableLimiter) AdjustLimit(http.Header) error {
 	return nil
 }
 
+func (rl *nonadjustableLimiter) Pause(d time.Duration) {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+	if until := rl.clock.Now().Add(d); until.After(rl.pausedUntil) {
+		rl.pausedUntil = until
+	}
+}
+
 func LimitFromHeader(header http.Header, headerKeys HeaderKeys) (Limit, error) {
 	usedHeader := header.Get(headerKeys.UsedKey)
 	var remaining int
@@ -67,16 +129,52 @@ func LimitFromHeader(header http.Header, headerKeys HeaderKeys) (Limit, error) {
 	return headerLimit, nil
 }
 
+// RetryAfter returns the duration to wait before retrying a rate limited
+// request. It is taken from the Retry-After header, given either in seconds or
+// as HTTP date, and from the reset header of the rate limit otherwise.
+func RetryAfter(header http.Header, headerKeys HeaderKeys, now time.Time) (time.Duration, bool) {
+	if retryAfter := header.Get("Retry-After"); retryAfter != "" {
+		if seconds, err := strconv.Atoi(retryAfter); err == nil && seconds >= 0 {
+			return time.Duration(seconds) * time.Second, true
+		}
+		if date, err := http.ParseTime(retryAfter); err == nil {
+			if wait := date.Sub(now); wait > 0 {
+				return wait, true
+			}
+			return 0, true
+		}
+	}
+	if headerKeys.ResetsAfterKey == "" {
+		return 0, false
+	}
+	seconds, err := strconv.Atoi(header.Get(headerKeys.ResetsAfterKey))
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
 type Limit struct {
 	Limit             int
 	Remaining         int
 	ResetAfterSeconds int
 }
 
+// DefaultWindow is the length of the rate limit window assumed until the
+// next response reports the time of the reset.
+const DefaultWindow = time.Hour
+
 func NewFromHeader(h HeaderKeys) (*HeaderLimiter, error) {
+	return newFromHeaderWithClock(h, realClock{}), nil
+}
+
+func newFromHeaderWithClock(h HeaderKeys, clock Clock) *HeaderLimiter {
 	return &HeaderLimiter{
 		headerKeys: h,
-	}, nil
+		clock:      clock,
+		window:     DefaultWindow,
+		adjusted:   make(chan struct{}),
+	}
 }
 
 type HeaderKeys struct {
@@ -86,41 +184,196 @@ type HeaderKeys struct {
 	ResetsAfterKey string
 }
 
+// HeaderLimiter is a limiter reconciling its state with the rate limit
+// headers of every response. Each call to Wait takes one of the remaining
+// requests. If no requests remain, callers are blocked until the limit is
+// reset. As long as no header has been observed requests are not limited.
 type HeaderLimiter struct {
 	headerKeys HeaderKeys
+	clock      Clock
+	window     time.Duration
 	mutex      sync.Mutex
-	limiter    *rate.Limiter
+	observed   bool
+	limit      int
+	remaining  int
+	resetAt    time.Time
+	// pausedUntil blocks all callers regardless of the remaining requests.
+	pausedUntil time.Time
+	// adjusted is closed and replaced on every adjustment to wake blocked
+	// callers.
+	adjusted        chan struct{}
+	stateStore      StateStore
+	priorityOptions PriorityOptions
+	// waiting counts the blocked callers by priority. Callers are only
+	// admitted while no caller of higher priority is blocked.
+	waiting map[Priority]int
 }
 
-func (r *HeaderLimiter) Wait(ctx context.Context) error {
+// SetPriorityOptions configures how requests of different priorities are
+// admitted. The priority of a request is taken from the context passed to
+// Wait.
+func (r *HeaderLimiter) SetPriorityOptions(options PriorityOptions) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.priorityOptions = options
+}
+
+// SetStateStore restores the state saved within the store and saves the
+// state on every adjustment from now on.
+func (r *HeaderLimiter) SetStateStore(store StateStore) error {
+	state, err := store.State()
+	if err != nil {
+		return fmt.Errorf("error getting rate limit state from store: %w", err)
+	}
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
-	if r.limiter == nil {
+	r.stateStore = store
+	if state == nil {
 		return nil
 	}
-	return r.limiter.Wait(ctx)
+	r.observed = true
+	r.limit = state.Limit.Limit
+	r.remaining = state.Limit.Remaining
+	r.resetAt = state.ResetAt
+	return nil
 }
 
-func (r *HeaderLimiter) AdjustLimit(header http.Header) error {
+func (r *HeaderLimiter) Wait(ctx context.Context) error {
+	priority := PriorityFromContext(ctx)
+	r.mutex.Lock()
+	options := r.priorityOptions
+	r.mutex.Unlock()
+	deferred := false
+	waiting := false
+	defer func() {
+		if waiting {
+			r.stopWaiting(priority)
+		}
+	}()
+	for {
+		wait, adjusted, reserved, ok := r.take(priority)
+		if ok {
+			return nil
+		}
+		if priority < PriorityNormal && options.DropLow {
+			if options.OnDropped != nil {
+				options.OnDropped(priority)
+			}
+			return ErrDropped
+		}
+		if !waiting {
+			waiting = true
+			r.startWaiting(priority)
+		}
+		if reserved && !deferred {
+			deferred = true
+			if options.OnDeferred != nil {
+				options.OnDeferred(priority)
+			}
+		}
+		// Every adjustment wakes all blocked callers, so the timer is stopped
+		// to not pile up timers lasting until the reset.
+		timer := r.clock.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C():
+		case <-adjusted:
+		}
+		timer.Stop()
+	}
+}
+
+func (r *HeaderLimiter) startWaiting(priority Priority) {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
-	if r.limiter != nil {
-		return nil
+	if r.waiting == nil {
+		r.waiting = make(map[Priority]int)
+	}
+	r.waiting[priority]++
+}
+
+// stopWaiting wakes all blocked callers, as callers of lower priority may be
+// admitted now.
+func (r *HeaderLimiter) stopWaiting(priority Priority) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.waiting[priority]--
+	close(r.adjusted)
+	r.adjusted = make(chan struct{})
+}
+
+// waitingAbove reports whether any caller of higher priority is blocked. It
+// needs to be called with the mutex held.
+func (r *HeaderLimiter) waitingAbove(priority Priority) bool {
+	for p, n := range r.waiting {
+		if p > priority && n > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// take takes one of the remaining requests for a request of the priority.
+// If none remains, or a caller of higher priority is blocked, it returns the
+// time to wait until the limit is reset, a channel closed on the next
+// adjustment and whether the request is kept back in favor of higher
+// priorities.
+func (r *HeaderLimiter) take(priority Priority) (time.Duration, <-chan struct{}, bool, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	now := r.clock.Now()
+	if now.Before(r.pausedUntil) {
+		return r.pausedUntil.Sub(now), r.adjusted, false, false
+	}
+	if !r.observed {
+		return 0, nil, false, true
 	}
-	rl, err := r.limiterFromHeader(header)
+	for !now.Before(r.resetAt) {
+		r.remaining = r.limit
+		r.resetAt = r.resetAt.Add(r.window)
+	}
+	if r.waitingAbove(priority) {
+		return r.resetAt.Sub(now), r.adjusted, true, false
+	}
+	if r.remaining > r.priorityOptions.reserved(r.limit, priority) {
+		r.remaining--
+		return 0, nil, false, true
+	}
+	return r.resetAt.Sub(now), r.adjusted, r.remaining > 0, false
+}
+
+func (r *HeaderLimiter) AdjustLimit(header http.Header) error {
+	limit, err := LimitFromHeader(header, r.headerKeys)
 	if err != nil {
 		return fmt.Errorf("error adjusting limit by header: %w", err)
 	}
-	r.limiter = rl
+	r.mutex.Lock()
+	r.observed = true
+	r.limit = limit.Limit
+	r.remaining = limit.Remaining
+	r.resetAt = r.clock.Now().Add(time.Duration(limit.ResetAfterSeconds) * time.Second)
+	close(r.adjusted)
+	r.adjusted = make(chan struct{})
+	state := State{Limit: limit, ResetAt: r.resetAt}
+	store := r.stateStore
+	r.mutex.Unlock()
+	if store == nil {
+		return nil
+	}
+	if err := store.Save(state); err != nil {
+		return fmt.Errorf("error saving rate limit state: %w", err)
+	}
 	return nil
 }
 
-func (r *HeaderLimiter) limiterFromHeader(header http.Header) (*rate.Limiter, error) {
-	limit, err := LimitFromHeader(header, r.headerKeys)
-	if err != nil {
-		return nil, fmt.Errorf("error getting limit from header: %v", err)
+func (r *HeaderLimiter) Pause(d time.Duration) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if until := r.clock.Now().Add(d); until.After(r.pausedUntil) {
+		r.pausedUntil = until
 	}
-	return rate.NewLimiter(rate.Every(time.Duration(limit.ResetAfterSeconds)*time.Second), limit.Remaining), nil
 }
 
 func NewLimiter(limit rate.Limit, b int) AdjustableLimiter {
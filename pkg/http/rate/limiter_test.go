@@ -0,0 +1,584 @@
+package rate
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+var testHeaderKeys = HeaderKeys{
+	LimitKey:       "Fitbit-Rate-Limit-Limit",
+	RemainingKey:   "Fitbit-Rate-Limit-Remaining",
+	ResetsAfterKey: "Fitbit-Rate-Limit-Reset",
+}
+
+type fakeTimer struct {
+	clock *fakeClock
+	at    time.Time
+	ch    chan time.Time
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.ch
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mutex.Lock()
+	defer t.clock.mutex.Unlock()
+	for i, timer := range t.clock.timers {
+		if timer == t {
+			t.clock.timers = append(t.clock.timers[:i], t.clock.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// fakeClock is a Clock only advancing on calls to Advance. Every new timer is
+// announced on the waiting channel so tests can synchronize with callers
+// blocked on a timer.
+type fakeClock struct {
+	mutex   sync.Mutex
+	now     time.Time
+	timers  []*fakeTimer
+	waiting chan time.Duration
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{
+		now:     time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC),
+		waiting: make(chan time.Duration, 16),
+	}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) NewTimer(d time.Duration) Timer {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	timer := &fakeTimer{clock: c, at: c.now.Add(d), ch: make(chan time.Time, 1)}
+	if d <= 0 {
+		timer.ch <- c.now
+	} else {
+		c.timers = append(c.timers, timer)
+	}
+	c.waiting <- d
+	return timer
+}
+
+// pendingTimers returns the number of timers neither fired nor stopped.
+func (c *fakeClock) pendingTimers() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return len(c.timers)
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.now = c.now.Add(d)
+	var pending []*fakeTimer
+	for _, timer := range c.timers {
+		if c.now.Before(timer.at) {
+			pending = append(pending, timer)
+			continue
+		}
+		timer.ch <- c.now
+	}
+	c.timers = pending
+}
+
+func limitHeader(limit, remaining, resetAfterSeconds int) http.Header {
+	header := http.Header{}
+	header.Set(testHeaderKeys.LimitKey, strconv.Itoa(limit))
+	header.Set(testHeaderKeys.RemainingKey, strconv.Itoa(remaining))
+	header.Set(testHeaderKeys.ResetsAfterKey, strconv.Itoa(resetAfterSeconds))
+	return header
+}
+
+// waitAsync calls Wait in the background and returns a channel receiving its
+// result.
+func waitAsync(ctx context.Context, limiter *HeaderLimiter) <-chan error {
+	done := make(chan error, 1)
+	go func() {
+		done <- limiter.Wait(ctx)
+	}()
+	return done
+}
+
+func expectBlocked(t *testing.T, clock *fakeClock, done <-chan error, wait time.Duration) {
+	t.Helper()
+	if got := <-clock.waiting; got != wait {
+		t.Fatalf("expected to wait for %s, got %s", wait, got)
+	}
+	select {
+	case err := <-done:
+		t.Fatalf("expected Wait to block, returned with %v", err)
+	default:
+	}
+}
+
+func expectAdmitted(t *testing.T, limiter *HeaderLimiter, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		wait, _, _, ok := limiter.take(PriorityNormal)
+		if !ok {
+			t.Fatalf("expected request %d to be admitted, would wait for %s", i+1, wait)
+		}
+	}
+}
+
+func TestHeaderLimiterUnlimitedWithoutHeader(t *testing.T) {
+	clock := newFakeClock()
+	limiter := newFromHeaderWithClock(testHeaderKeys, clock)
+
+	expectAdmitted(t, limiter, 1000)
+}
+
+func TestHeaderLimiterReconcilesEveryResponse(t *testing.T) {
+	clock := newFakeClock()
+	limiter := newFromHeaderWithClock(testHeaderKeys, clock)
+
+	if err := limiter.AdjustLimit(limitHeader(150, 2, 3600)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectAdmitted(t, limiter, 2)
+	if _, _, _, ok := limiter.take(PriorityNormal); ok {
+		t.Fatalf("expected limiter to be exhausted")
+	}
+
+	// The server reports more remaining requests than tracked locally.
+	if err := limiter.AdjustLimit(limitHeader(150, 5, 3000)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectAdmitted(t, limiter, 5)
+	wait, _, _, ok := limiter.take(PriorityNormal)
+	if ok {
+		t.Fatalf("expected limiter to be exhausted")
+	}
+	if wait != 3000*time.Second {
+		t.Errorf("expected to wait for %s, got %s", 3000*time.Second, wait)
+	}
+
+	// The server reports fewer remaining requests than tracked locally.
+	if err := limiter.AdjustLimit(limitHeader(150, 100, 2000)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := limiter.AdjustLimit(limitHeader(150, 1, 1999)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectAdmitted(t, limiter, 1)
+	if _, _, _, ok := limiter.take(PriorityNormal); ok {
+		t.Fatalf("expected limiter to be exhausted")
+	}
+}
+
+func TestHeaderLimiterBlocksUntilReset(t *testing.T) {
+	clock := newFakeClock()
+	limiter := newFromHeaderWithClock(testHeaderKeys, clock)
+	if err := limiter.AdjustLimit(limitHeader(150, 0, 600)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	done := waitAsync(context.Background(), limiter)
+	expectBlocked(t, clock, done, 600*time.Second)
+
+	clock.Advance(599 * time.Second)
+	select {
+	case err := <-done:
+		t.Fatalf("expected Wait to block before reset, returned with %v", err)
+	default:
+	}
+
+	clock.Advance(time.Second)
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The limit has been refilled and one request has been taken.
+	expectAdmitted(t, limiter, 149)
+	if _, _, _, ok := limiter.take(PriorityNormal); ok {
+		t.Fatalf("expected limiter to be exhausted")
+	}
+}
+
+func TestHeaderLimiterRefillsEveryWindow(t *testing.T) {
+	clock := newFakeClock()
+	limiter := newFromHeaderWithClock(testHeaderKeys, clock)
+	if err := limiter.AdjustLimit(limitHeader(3, 0, 60)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	clock.Advance(time.Minute)
+	expectAdmitted(t, limiter, 3)
+	wait, _, _, ok := limiter.take(PriorityNormal)
+	if ok {
+		t.Fatalf("expected limiter to be exhausted")
+	}
+	if wait != DefaultWindow {
+		t.Errorf("expected to wait for %s, got %s", DefaultWindow, wait)
+	}
+
+	clock.Advance(3 * DefaultWindow)
+	expectAdmitted(t, limiter, 3)
+	wait, _, _, _ = limiter.take(PriorityNormal)
+	if wait != DefaultWindow {
+		t.Errorf("expected to wait for %s, got %s", DefaultWindow, wait)
+	}
+}
+
+func TestHeaderLimiterWaitHonorsContext(t *testing.T) {
+	clock := newFakeClock()
+	limiter := newFromHeaderWithClock(testHeaderKeys, clock)
+	if err := limiter.AdjustLimit(limitHeader(150, 0, 600)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := waitAsync(ctx, limiter)
+	expectBlocked(t, clock, done, 600*time.Second)
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected %v, got %v", context.Canceled, err)
+	}
+}
+
+func TestHeaderLimiterWakesWaitersOnAdjust(t *testing.T) {
+	clock := newFakeClock()
+	limiter := newFromHeaderWithClock(testHeaderKeys, clock)
+	if err := limiter.AdjustLimit(limitHeader(150, 0, 600)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	done := waitAsync(context.Background(), limiter)
+	expectBlocked(t, clock, done, 600*time.Second)
+
+	// A response of a request already in flight reports the reset earlier.
+	if err := limiter.AdjustLimit(limitHeader(150, 0, 30)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectBlocked(t, clock, done, 30*time.Second)
+	clock.Advance(30 * time.Second)
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestHeaderLimiterAdjustLimitInvalidHeader(t *testing.T) {
+	limiter := newFromHeaderWithClock(testHeaderKeys, newFakeClock())
+
+	if err := limiter.AdjustLimit(http.Header{}); err == nil {
+		t.Fatalf("expected error for missing headers")
+	}
+	expectAdmitted(t, limiter, 1)
+}
+
+func TestHeaderLimiterPause(t *testing.T) {
+	clock := newFakeClock()
+	limiter := newFromHeaderWithClock(testHeaderKeys, clock)
+	if err := limiter.AdjustLimit(limitHeader(150, 10, 600)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	limiter.Pause(time.Minute)
+	// A shorter pause does not shorten the running one.
+	limiter.Pause(time.Second)
+
+	done := waitAsync(context.Background(), limiter)
+	expectBlocked(t, clock, done, time.Minute)
+
+	clock.Advance(time.Minute)
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectAdmitted(t, limiter, 9)
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		header   http.Header
+		expected time.Duration
+		ok       bool
+	}{
+		{
+			name:     "retry after seconds",
+			header:   http.Header{"Retry-After": {"120"}, "Fitbit-Rate-Limit-Reset": {"600"}},
+			expected: 2 * time.Minute,
+			ok:       true,
+		},
+		{
+			name:     "retry after date",
+			header:   http.Header{"Retry-After": {now.Add(time.Hour).Format(http.TimeFormat)}},
+			expected: time.Hour,
+			ok:       true,
+		},
+		{
+			name:     "retry after date in the past",
+			header:   http.Header{"Retry-After": {now.Add(-time.Hour).Format(http.TimeFormat)}},
+			expected: 0,
+			ok:       true,
+		},
+		{
+			name:     "rate limit reset",
+			header:   http.Header{"Retry-After": {"soon"}, "Fitbit-Rate-Limit-Reset": {"600"}},
+			expected: 10 * time.Minute,
+			ok:       true,
+		},
+		{
+			name:   "no header",
+			header: http.Header{},
+			ok:     false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, ok := RetryAfter(tt.header, testHeaderKeys, now)
+			if ok != tt.ok {
+				t.Fatalf("expected ok to be %t, got %t", tt.ok, ok)
+			}
+			if actual != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestHeaderLimiterPriorityReserve(t *testing.T) {
+	clock := newFakeClock()
+	limiter := newFromHeaderWithClock(testHeaderKeys, clock)
+	var deferred []Priority
+	limiter.SetPriorityOptions(PriorityOptions{
+		Reserve:    0.1,
+		OnDeferred: func(p Priority) { deferred = append(deferred, p) },
+	})
+	if err := limiter.AdjustLimit(limitHeader(100, 12, 600)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Low priority requests are admitted while more than 10 requests remain.
+	for i := 0; i < 2; i++ {
+		if _, _, _, ok := limiter.take(PriorityLow); !ok {
+			t.Fatalf("expected low priority request %d to be admitted", i+1)
+		}
+	}
+	if _, _, reserved, ok := limiter.take(PriorityLow); ok || !reserved {
+		t.Fatalf("expected low priority request to be kept from the reserve")
+	}
+	// Normal priority requests are admitted while more than 5 requests remain.
+	for i := 0; i < 5; i++ {
+		if _, _, _, ok := limiter.take(PriorityNormal); !ok {
+			t.Fatalf("expected normal priority request %d to be admitted", i+1)
+		}
+	}
+	if _, _, reserved, ok := limiter.take(PriorityNormal); ok || !reserved {
+		t.Fatalf("expected normal priority request to be kept from the reserve")
+	}
+	// High priority requests may spend the whole limit.
+	for i := 0; i < 5; i++ {
+		if _, _, _, ok := limiter.take(PriorityHigh); !ok {
+			t.Fatalf("expected high priority request %d to be admitted", i+1)
+		}
+	}
+	if _, _, reserved, ok := limiter.take(PriorityHigh); ok || reserved {
+		t.Fatalf("expected limiter to be exhausted")
+	}
+
+	if err := limiter.AdjustLimit(limitHeader(100, 10, 300)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	done := waitAsync(WithPriority(context.Background(), PriorityLow), limiter)
+	expectBlocked(t, clock, done, 300*time.Second)
+	clock.Advance(300 * time.Second)
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(deferred) != 1 || deferred[0] != PriorityLow {
+		t.Errorf("expected one deferred low priority request, got %v", deferred)
+	}
+}
+
+func TestHeaderLimiterDropsLowPriority(t *testing.T) {
+	limiter := newFromHeaderWithClock(testHeaderKeys, newFakeClock())
+	var dropped []Priority
+	limiter.SetPriorityOptions(PriorityOptions{
+		Reserve:   0.1,
+		DropLow:   true,
+		OnDropped: func(p Priority) { dropped = append(dropped, p) },
+	})
+	if err := limiter.AdjustLimit(limitHeader(150, 15, 600)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err := limiter.Wait(WithPriority(context.Background(), PriorityLow))
+	if !errors.Is(err, ErrDropped) {
+		t.Fatalf("expected %v, got %v", ErrDropped, err)
+	}
+	if len(dropped) != 1 || dropped[0] != PriorityLow {
+		t.Errorf("expected one dropped low priority request, got %v", dropped)
+	}
+	if err := limiter.Wait(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestHeaderLimiterAdmitsWaitersByPriority(t *testing.T) {
+	clock := newFakeClock()
+	limiter := newFromHeaderWithClock(testHeaderKeys, clock)
+	// A single request per window.
+	if err := limiter.AdjustLimit(limitHeader(1, 0, 600)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	low := waitAsync(WithPriority(context.Background(), PriorityLow), limiter)
+	expectBlocked(t, clock, low, 600*time.Second)
+	normal := waitAsync(WithPriority(context.Background(), PriorityNormal), limiter)
+	expectBlocked(t, clock, normal, 600*time.Second)
+	high := waitAsync(WithPriority(context.Background(), PriorityHigh), limiter)
+	expectBlocked(t, clock, high, 600*time.Second)
+	// Waiters woken by each other keep announcing their timers.
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		for {
+			select {
+			case <-clock.waiting:
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	for _, expected := range []struct {
+		name    string
+		done    <-chan error
+		blocked []<-chan error
+	}{
+		{name: "high", done: high, blocked: []<-chan error{normal, low}},
+		{name: "normal", done: normal, blocked: []<-chan error{low}},
+		{name: "low", done: low},
+	} {
+		clock.Advance(time.Hour)
+		select {
+		case err := <-expected.done:
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("expected the %s priority waiter to be admitted", expected.name)
+		}
+		for _, done := range expected.blocked {
+			select {
+			case err := <-done:
+				t.Fatalf("expected lower priority waiters to stay blocked after the %s priority waiter, returned with %v", expected.name, err)
+			default:
+			}
+		}
+	}
+}
+
+func TestHeaderLimiterDropsLowPriorityWhenExhausted(t *testing.T) {
+	tests := []struct {
+		name      string
+		remaining int
+		// blockedHigh blocks a high priority caller before the low
+		// priority request.
+		blockedHigh bool
+	}{
+		{name: "below reserve", remaining: 15},
+		{name: "exhausted", remaining: 0},
+		{name: "higher priority waiting", remaining: 0, blockedHigh: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			clock := newFakeClock()
+			limiter := newFromHeaderWithClock(testHeaderKeys, clock)
+			var dropped []Priority
+			limiter.SetPriorityOptions(PriorityOptions{
+				Reserve:   0.1,
+				DropLow:   true,
+				OnDropped: func(p Priority) { dropped = append(dropped, p) },
+			})
+			if err := limiter.AdjustLimit(limitHeader(150, test.remaining, 600)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if test.blockedHigh {
+				ctx, cancel := context.WithCancel(WithPriority(context.Background(), PriorityHigh))
+				defer cancel()
+				expectBlocked(t, clock, waitAsync(ctx, limiter), 600*time.Second)
+			}
+
+			err := limiter.Wait(WithPriority(context.Background(), PriorityLow))
+
+			if !errors.Is(err, ErrDropped) {
+				t.Fatalf("expected %v, got %v", ErrDropped, err)
+			}
+			if len(dropped) != 1 || dropped[0] != PriorityLow {
+				t.Errorf("expected one dropped low priority request, got %v", dropped)
+			}
+		})
+	}
+}
+
+func TestHeaderLimiterDefersBehindHigherPriority(t *testing.T) {
+	clock := newFakeClock()
+	limiter := newFromHeaderWithClock(testHeaderKeys, clock)
+	var deferred []Priority
+	limiter.SetPriorityOptions(PriorityOptions{
+		OnDeferred: func(p Priority) { deferred = append(deferred, p) },
+	})
+	if err := limiter.AdjustLimit(limitHeader(150, 0, 600)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	high := waitAsync(WithPriority(ctx, PriorityHigh), limiter)
+	expectBlocked(t, clock, high, 600*time.Second)
+	if len(deferred) != 0 {
+		t.Fatalf("expected requests blocked by the exhausted limit not to be deferred, got %v", deferred)
+	}
+
+	normal := waitAsync(WithPriority(ctx, PriorityNormal), limiter)
+	expectBlocked(t, clock, normal, 600*time.Second)
+
+	if len(deferred) != 1 || deferred[0] != PriorityNormal {
+		t.Errorf("expected one deferred normal priority request, got %v", deferred)
+	}
+}
+
+func TestHeaderLimiterStopsTimers(t *testing.T) {
+	clock := newFakeClock()
+	limiter := newFromHeaderWithClock(testHeaderKeys, clock)
+	if err := limiter.AdjustLimit(limitHeader(150, 0, 600)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := waitAsync(ctx, limiter)
+	expectBlocked(t, clock, done, 600*time.Second)
+
+	for i := 0; i < 3; i++ {
+		if err := limiter.AdjustLimit(limitHeader(150, 0, 600)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expectBlocked(t, clock, done, 600*time.Second)
+	}
+	if pending := clock.pendingTimers(); pending != 1 {
+		t.Errorf("expected a single timer of the blocked caller, got %d", pending)
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected %v, got %v", context.Canceled, err)
+	}
+	if pending := clock.pendingTimers(); pending != 0 {
+		t.Errorf("expected the timer to be stopped, got %d pending timers", pending)
+	}
+}
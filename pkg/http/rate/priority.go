@@ -0,0 +1,84 @@
+package rate
+
+import (
+	"context"
+	"errors"
+	"math"
+)
+
+// ErrDropped is returned by Wait if a request is dropped in favor of requests
+// with higher priority.
+var ErrDropped = errors.New("request dropped by rate limiter")
+
+// Priority determines in which order requests are admitted when the rate
+// limit budget is tight. The zero value is PriorityNormal.
+type Priority int
+
+const (
+	PriorityLow    Priority = -1
+	PriorityNormal Priority = 0
+	PriorityHigh   Priority = 1
+)
+
+// Priorities contains all known priorities in ascending order.
+var Priorities = []Priority{PriorityLow, PriorityNormal, PriorityHigh}
+
+func (p Priority) String() string {
+	switch {
+	case p < PriorityNormal:
+		return "low"
+	case p > PriorityNormal:
+		return "high"
+	default:
+		return "normal"
+	}
+}
+
+type priorityKey struct{}
+
+// WithPriority returns a context carrying the priority of the requests sent
+// with it.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// PriorityFromContext returns the priority carried by the context, or
+// PriorityNormal if there is none.
+func PriorityFromContext(ctx context.Context) Priority {
+	if p, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return p
+	}
+	return PriorityNormal
+}
+
+// PriorityOptions configure how a HeaderLimiter admits requests of different
+// priorities.
+type PriorityOptions struct {
+	// Reserve is the fraction of the limit kept for requests of higher
+	// priority. Low priority requests are only admitted while more requests
+	// than the reserve remain, normal priority requests while more than half
+	// of it remain. High priority requests may spend the whole limit.
+	Reserve float64
+	// DropLow drops low priority requests which cannot be admitted instead of
+	// deferring them, e.g. below the reserve or once the limit is exhausted.
+	DropLow bool
+	// OnDeferred is called whenever a request is deferred to keep the reserve
+	// or as requests of higher priority are waiting.
+	OnDeferred func(Priority)
+	// OnDropped is called whenever a request is dropped to keep the reserve.
+	OnDropped func(Priority)
+}
+
+// reserved returns the number of requests which need to remain for a request
+// of the priority to be admitted.
+func (o PriorityOptions) reserved(limit int, p Priority) int {
+	reserve := int(math.Ceil(float64(limit) * o.Reserve))
+	switch {
+	case p < PriorityNormal:
+		return reserve
+	case p > PriorityNormal:
+		return 0
+	default:
+		return reserve / 2
+	}
+}
@@ -0,0 +1,95 @@
+package rate
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/mitch000001/fitbit-exporter/pkg/atomicfile"
+)
+
+// State is the rate limit observed last and the time it will be reset.
+type State struct {
+	Limit   Limit     `json:"limit"`
+	ResetAt time.Time `json:"reset_at"`
+}
+
+// LimitAt returns the limit with the seconds after which it will be reset
+// relative to now.
+func (s State) LimitAt(now time.Time) Limit {
+	limit := s.Limit
+	limit.ResetAfterSeconds = 0
+	if resetAfter := s.ResetAt.Sub(now); resetAfter > 0 {
+		limit.ResetAfterSeconds = int(resetAfter.Round(time.Second) / time.Second)
+	}
+	return limit
+}
+
+// StateStore persists the rate limit state across restarts.
+type StateStore interface {
+	// State returns the saved state, or nil if none has been saved yet.
+	State() (*State, error)
+	Save(State) error
+}
+
+// NewJSONFileStateStore returns a StateStore keeping the state in the JSON
+// file. The file is written atomically. An unreadable or corrupt file is
+// treated as if no state has been saved yet, as the state is reconciled with
+// the next response anyway.
+func NewJSONFileStateStore(filePath string) (StateStore, error) {
+	store := &jsonFileStateStore{
+		filePath: filePath,
+	}
+	if err := store.load(); err != nil {
+		log.Printf("Error loading rate limit state, starting without: %v", err)
+	}
+	return store, nil
+}
+
+type jsonFileStateStore struct {
+	filePath string
+	state    *State
+	mutex    sync.Mutex
+}
+
+func (s *jsonFileStateStore) State() (*State, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.state, nil
+}
+
+func (s *jsonFileStateStore) Save(state State) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error marshaling json rate limit state: %w", err)
+	}
+	if err := atomicfile.WriteFile(s.filePath, append(data, '\n')); err != nil {
+		return fmt.Errorf("error writing rate limit state file: %w", err)
+	}
+	s.state = &state
+	return nil
+}
+
+func (s *jsonFileStateStore) load() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, err := os.Stat(s.filePath); os.IsNotExist(err) {
+		return nil
+	}
+	file, err := os.Open(s.filePath)
+	if err != nil {
+		return fmt.Errorf("error reading rate limit state file: %w", err)
+	}
+	defer file.Close()
+	var state State
+	if err := json.NewDecoder(file).Decode(&state); err != nil {
+		return fmt.Errorf("error unmarshaling json rate limit state: %w", err)
+	}
+	s.state = &state
+	return nil
+}
@@ -0,0 +1,120 @@
+package rate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHeaderLimiterRestoresStateAfterRestart(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "rate_limit.json")
+	clock := newFakeClock()
+
+	store, err := NewJSONFileStateStore(filePath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	limiter := newFromHeaderWithClock(testHeaderKeys, clock)
+	if err := limiter.SetStateStore(store); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := limiter.AdjustLimit(limitHeader(150, 2, 600)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Restart with a new store and limiter reading the same file.
+	clock.Advance(time.Minute)
+	store, err = NewJSONFileStateStore(filePath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	state, err := store.State()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if state == nil {
+		t.Fatalf("expected state to be restored")
+	}
+	if limit := state.LimitAt(clock.Now()); limit.ResetAfterSeconds != 540 {
+		t.Errorf("expected reset after %d seconds, got %d", 540, limit.ResetAfterSeconds)
+	}
+	limiter = newFromHeaderWithClock(testHeaderKeys, clock)
+	if err := limiter.SetStateStore(store); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectAdmitted(t, limiter, 2)
+	wait, _, _, ok := limiter.take(PriorityNormal)
+	if ok {
+		t.Fatalf("expected limiter to be exhausted")
+	}
+	if wait != 9*time.Minute {
+		t.Errorf("expected to wait for %s, got %s", 9*time.Minute, wait)
+	}
+}
+
+func TestJSONFileStateStoreWithoutFile(t *testing.T) {
+	store, err := NewJSONFileStateStore(filepath.Join(t.TempDir(), "rate_limit.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	state, err := store.State()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if state != nil {
+		t.Errorf("expected no state, got %+v", state)
+	}
+
+	limiter := newFromHeaderWithClock(testHeaderKeys, newFakeClock())
+	if err := limiter.SetStateStore(store); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectAdmitted(t, limiter, 1000)
+}
+
+func TestJSONFileStateStoreWithTruncatedFile(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "rate_limit.json")
+	if err := os.WriteFile(filePath, []byte(`{"limit":{"Limit":150,"Rem`), 0600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	store, err := NewJSONFileStateStore(filePath)
+	if err != nil {
+		t.Fatalf("expected a truncated file to be ignored, got %v", err)
+	}
+	state, err := store.State()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if state != nil {
+		t.Errorf("expected no state, got %+v", state)
+	}
+	limiter := newFromHeaderWithClock(testHeaderKeys, newFakeClock())
+	if err := limiter.SetStateStore(store); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := limiter.AdjustLimit(limitHeader(150, 2, 600)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	store, err = NewJSONFileStateStore(filePath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	state, err = store.State()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if state == nil || state.Limit.Remaining != 2 {
+		t.Errorf("expected the state to be overwritten, got %+v", state)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected only the state file to be left, got %d files", len(entries))
+	}
+}
@@ -1,15 +1,38 @@
 package rate
 
 import (
-	"fmt"
+	"io"
 	"net/http"
+	"time"
 )
 
+// DefaultRetryAfter is the duration the limiter is paused for after a rate
+// limited response without any hint when to retry.
+const DefaultRetryAfter = time.Minute
+
+// TransportOptions configure how the transport handles rate limited responses.
+type TransportOptions struct {
+	// HeaderKeys are used to read the reset of the rate limit if a rate
+	// limited response has no Retry-After header.
+	HeaderKeys HeaderKeys
+	// Retry retries idempotent requests once when the rate limit is reset.
+	Retry bool
+	// OnRateLimited is called for every rate limited response.
+	OnRateLimited func(*http.Response)
+}
+
 // NewRateLimitingTransport returns a http RoundTripper which honors the specified rate limit
 func NewTransport(rl AdjustableLimiter, transport http.RoundTripper) http.RoundTripper {
+	return NewTransportWithOptions(rl, transport, TransportOptions{})
+}
+
+// NewTransportWithOptions returns a http RoundTripper which honors the
+// specified rate limit and handles rate limited responses as configured.
+func NewTransportWithOptions(rl AdjustableLimiter, transport http.RoundTripper, options TransportOptions) http.RoundTripper {
 	return &rateLimitingTransport{
 		wrappedTransport: transport,
 		ratelimiter:      rl,
+		options:          options,
 	}
 }
 
@@ -17,11 +40,26 @@ func NewTransport(rl AdjustableLimiter, transport http.RoundTripper) http.RoundT
 type rateLimitingTransport struct {
 	wrappedTransport http.RoundTripper
 	ratelimiter      AdjustableLimiter
+	options          TransportOptions
 }
 
-// RoundTrip dispatches the HTTP request to the network
+// RoundTrip dispatches the HTTP request to the network. If the response is
+// rate limited the limiter is paused until the limit is reset and idempotent
+// requests are retried once if configured.
 func (r *rateLimitingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
-	fmt.Println("Waiting for rate limiter")
+	response, err := r.roundTrip(request)
+	if err != nil || response.StatusCode != http.StatusTooManyRequests {
+		return response, err
+	}
+	if !r.options.Retry || !isRetryable(request) {
+		return response, nil
+	}
+	io.Copy(io.Discard, response.Body)
+	response.Body.Close()
+	return r.roundTrip(request)
+}
+
+func (r *rateLimitingTransport) roundTrip(request *http.Request) (*http.Response, error) {
 	err := r.ratelimiter.Wait(request.Context()) // This is a blocking call. Honors the rate limit
 	if err != nil {
 		return nil, err
@@ -31,5 +69,25 @@ func (r *rateLimitingTransport) RoundTrip(request *http.Request) (*http.Response
 		return response, err
 	}
 	r.ratelimiter.AdjustLimit(response.Header)
+	if response.StatusCode == http.StatusTooManyRequests {
+		if r.options.OnRateLimited != nil {
+			r.options.OnRateLimited(response)
+		}
+		retryAfter, ok := RetryAfter(response.Header, r.options.HeaderKeys, time.Now())
+		if !ok {
+			retryAfter = DefaultRetryAfter
+		}
+		r.ratelimiter.Pause(retryAfter)
+	}
 	return response, err
 }
+
+// isRetryable reports whether the request is idempotent and can be sent again.
+func isRetryable(request *http.Request) bool {
+	switch request.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions:
+	default:
+		return false
+	}
+	return request.Body == nil || request.Body == http.NoBody
+}
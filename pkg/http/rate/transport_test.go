@@ -0,0 +1,174 @@
+package rate
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingLimiter admits every request and records the calls of the
+// transport.
+type recordingLimiter struct {
+	mutex       sync.Mutex
+	waits       int
+	adjustments int
+	pauses      []time.Duration
+}
+
+func (l *recordingLimiter) Wait(context.Context) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.waits++
+	return nil
+}
+
+func (l *recordingLimiter) AdjustLimit(http.Header) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.adjustments++
+	return nil
+}
+
+func (l *recordingLimiter) Pause(d time.Duration) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.pauses = append(l.pauses, d)
+}
+
+// newRateLimitedServer returns a server rejecting the first request with 429
+// and the header, and answering all further requests with 200.
+func newRateLimitedServer(t *testing.T, header http.Header) (*httptest.Server, *int) {
+	t.Helper()
+	var mutex sync.Mutex
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		requests++
+		first := requests == 1
+		mutex.Unlock()
+		if first {
+			for key, values := range header {
+				w.Header()[key] = values
+			}
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"errors":[{"errorType":"request","message":"Too Many Requests"}]}`))
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestTransportRetriesRateLimitedRequest(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		pause  time.Duration
+	}{
+		{name: "retry after", header: http.Header{"Retry-After": []string{"30"}}, pause: 30 * time.Second},
+		{name: "rate limit reset", header: limitHeader(150, 0, 120), pause: 120 * time.Second},
+		{name: "no hint", header: http.Header{}, pause: DefaultRetryAfter},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, requests := newRateLimitedServer(t, test.header)
+			limiter := &recordingLimiter{}
+			var rateLimited []*http.Response
+			client := &http.Client{Transport: NewTransportWithOptions(limiter, http.DefaultTransport, TransportOptions{
+				HeaderKeys: testHeaderKeys,
+				Retry:      true,
+				OnRateLimited: func(response *http.Response) {
+					rateLimited = append(rateLimited, response)
+				},
+			})}
+
+			response, err := client.Get(server.URL)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			response.Body.Close()
+
+			if response.StatusCode != http.StatusOK {
+				t.Errorf("expected the retry to succeed, got status %d", response.StatusCode)
+			}
+			if *requests != 2 {
+				t.Errorf("expected 2 requests, got %d", *requests)
+			}
+			if limiter.waits != 2 || limiter.adjustments != 2 {
+				t.Errorf("expected both requests to pass the limiter, got %d waits and %d adjustments", limiter.waits, limiter.adjustments)
+			}
+			if len(limiter.pauses) != 1 || limiter.pauses[0] != test.pause {
+				t.Errorf("expected the limiter to be paused for %v, got %v", test.pause, limiter.pauses)
+			}
+			if len(rateLimited) != 1 || rateLimited[0].StatusCode != http.StatusTooManyRequests {
+				t.Errorf("expected a single rate limited response, got %d", len(rateLimited))
+			}
+		})
+	}
+}
+
+func TestTransportRateLimitedWithoutRetry(t *testing.T) {
+	tests := []struct {
+		name    string
+		options TransportOptions
+		method  string
+	}{
+		{name: "retry disabled", options: TransportOptions{}, method: http.MethodGet},
+		{name: "request not idempotent", options: TransportOptions{Retry: true}, method: http.MethodPost},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server, requests := newRateLimitedServer(t, http.Header{"Retry-After": []string{"30"}})
+			limiter := &recordingLimiter{}
+			client := &http.Client{Transport: NewTransportWithOptions(limiter, http.DefaultTransport, test.options)}
+			var body io.Reader
+			if test.method == http.MethodPost {
+				body = strings.NewReader("{}")
+			}
+			request, err := http.NewRequest(test.method, server.URL, body)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			response, err := client.Do(request)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			response.Body.Close()
+
+			if response.StatusCode != http.StatusTooManyRequests {
+				t.Errorf("expected the rate limited response, got status %d", response.StatusCode)
+			}
+			if *requests != 1 {
+				t.Errorf("expected a single request, got %d", *requests)
+			}
+			if len(limiter.pauses) != 1 || limiter.pauses[0] != 30*time.Second {
+				t.Errorf("expected the limiter to be paused for 30s, got %v", limiter.pauses)
+			}
+		})
+	}
+}
+
+func TestTransportPausesHeaderLimiter(t *testing.T) {
+	server, _ := newRateLimitedServer(t, http.Header{"Retry-After": []string{"30"}})
+	clock := newFakeClock()
+	limiter := newFromHeaderWithClock(testHeaderKeys, clock)
+	client := &http.Client{Transport: NewTransport(limiter, http.DefaultTransport)}
+
+	response, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	response.Body.Close()
+
+	wait, _, _, _ := limiter.take(PriorityNormal)
+	if wait != 30*time.Second {
+		t.Errorf("expected requests to wait for %v, got %v", 30*time.Second, wait)
+	}
+}
@@ -0,0 +1,100 @@
+package scheduler
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/mitch000001/fitbit-exporter/pkg/http/rate"
+)
+
+// Planner spreads the requests of the scheduled jobs evenly across the rate
+// limit window. It stretches the job intervals if the remaining budget does
+// not suffice until the window resets and shrinks them if budget is left.
+type Planner struct {
+	// Window is the length of the rate limit window.
+	Window time.Duration
+	// Reserve is the ratio of the limit which is never planned to be used.
+	Reserve float64
+	// MaxSpeedup is the maximum factor by which intervals are shrunk.
+	MaxSpeedup float64
+
+	mutex      sync.Mutex
+	limit      *rate.Limit
+	observedAt time.Time
+}
+
+// NewPlanner returns a Planner for the Fitbit rate limit window of one hour,
+// keeping the given ratio of the limit in reserve and running jobs at most
+// twice as often as their base interval. The reserve should match the one of
+// the rate limiter, as the limiter defers requests eating into it.
+func NewPlanner(reserve float64) *Planner {
+	return &Planner{
+		Window:     time.Hour,
+		Reserve:    reserve,
+		MaxSpeedup: 2,
+	}
+}
+
+// Observe updates the planner with the rate limit read from a response.
+func (p *Planner) Observe(limit rate.Limit) {
+	p.observe(limit, time.Now())
+}
+
+func (p *Planner) observe(limit rate.Limit, now time.Time) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.limit = &limit
+	p.observedAt = now
+}
+
+// Budget describes the rate limit budget at a point in time.
+type Budget struct {
+	Limit     int
+	Remaining int
+	Reserve   int
+	ResetsAt  time.Time
+	Observed  bool
+}
+
+// Budget returns the budget at the given time as last observed.
+func (p *Planner) Budget(now time.Time) Budget {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.limit == nil {
+		return Budget{}
+	}
+	budget := Budget{
+		Limit:     p.limit.Limit,
+		Remaining: p.limit.Remaining,
+		Reserve:   int(math.Ceil(float64(p.limit.Limit) * p.Reserve)),
+		ResetsAt:  p.observedAt.Add(time.Duration(p.limit.ResetAfterSeconds) * time.Second),
+		Observed:  true,
+	}
+	for p.Window > 0 && !budget.ResetsAt.After(now) {
+		// The window has been reset since the last observation.
+		budget.Remaining = budget.Limit
+		budget.ResetsAt = budget.ResetsAt.Add(p.Window)
+	}
+	return budget
+}
+
+// Factor returns the factor to apply to the base intervals of the jobs given
+// their demand in requests per second. If the budget is exhausted it returns
+// the time to wait until the window resets.
+func (p *Planner) Factor(demand float64, now time.Time) (float64, time.Duration) {
+	budget := p.Budget(now)
+	if !budget.Observed || demand <= 0 {
+		return 1, 0
+	}
+	untilReset := budget.ResetsAt.Sub(now)
+	usable := budget.Remaining - budget.Reserve
+	if usable <= 0 {
+		return 1, untilReset
+	}
+	factor := demand / (float64(usable) / untilReset.Seconds())
+	if minFactor := 1 / p.MaxSpeedup; p.MaxSpeedup > 0 && factor < minFactor {
+		factor = minFactor
+	}
+	return factor, 0
+}
@@ -0,0 +1,90 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mitch000001/fitbit-exporter/pkg/http/rate"
+)
+
+func TestPlannerUnobserved(t *testing.T) {
+	p := NewPlanner(0.1)
+
+	factor, wait := p.Factor(1, time.Now())
+
+	if factor != 1 || wait != 0 {
+		t.Errorf("expected base intervals without any limit observed, got factor %v and wait %v", factor, wait)
+	}
+}
+
+func TestPlannerFactor(t *testing.T) {
+	now := time.Date(2021, 8, 1, 13, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		remaining int
+		demand    float64
+		factor    float64
+	}{
+		// 150 - 15 reserved requests within an hour allow for 135 requests.
+		{name: "demand matches budget", remaining: 150, demand: 135.0 / 3600, factor: 1},
+		{name: "demand exceeds budget", remaining: 150, demand: 270.0 / 3600, factor: 2},
+		{name: "budget partly spent", remaining: 60, demand: 90.0 / 3600, factor: 2},
+		{name: "speedup is capped", remaining: 150, demand: 10.0 / 3600, factor: 0.5},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := NewPlanner(0.1)
+			p.observe(rate.Limit{Limit: 150, Remaining: test.remaining, ResetAfterSeconds: 3600}, now)
+
+			factor, wait := p.Factor(test.demand, now)
+
+			if wait != 0 {
+				t.Errorf("expected no wait, got %v", wait)
+			}
+			if diff := factor - test.factor; diff > 1e-9 || diff < -1e-9 {
+				t.Errorf("expected factor %v, got %v", test.factor, factor)
+			}
+		})
+	}
+}
+
+func TestPlannerBudgetExhausted(t *testing.T) {
+	now := time.Date(2021, 8, 1, 13, 0, 0, 0, time.UTC)
+	p := NewPlanner(0.1)
+	// Only the reserve of 15 requests remains.
+	p.observe(rate.Limit{Limit: 150, Remaining: 15, ResetAfterSeconds: 600}, now)
+
+	factor, wait := p.Factor(1, now.Add(time.Minute))
+
+	if factor != 1 {
+		t.Errorf("expected factor 1, got %v", factor)
+	}
+	if wait != 9*time.Minute {
+		t.Errorf("expected to wait until the reset in %v, got %v", 9*time.Minute, wait)
+	}
+}
+
+func TestPlannerBudgetReset(t *testing.T) {
+	now := time.Date(2021, 8, 1, 13, 0, 0, 0, time.UTC)
+	p := NewPlanner(0.1)
+	p.observe(rate.Limit{Limit: 150, Remaining: 0, ResetAfterSeconds: 600}, now)
+
+	// The budget is reset after 10 minutes and again every hour, so the next
+	// reset after 14:15 is at 15:10.
+	budget := p.Budget(now.Add(time.Hour + 15*time.Minute))
+
+	expected := Budget{
+		Limit:     150,
+		Remaining: 150,
+		Reserve:   15,
+		ResetsAt:  now.Add(2*time.Hour + 10*time.Minute),
+		Observed:  true,
+	}
+	if budget != expected {
+		t.Errorf("expected budget %+v, got %+v", expected, budget)
+	}
+	factor, wait := p.Factor(135.0/3600, now.Add(10*time.Minute))
+	if wait != 0 || factor != 1 {
+		t.Errorf("expected the reset budget to be spread across the window, got factor %v and wait %v", factor, wait)
+	}
+}
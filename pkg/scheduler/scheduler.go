@@ -0,0 +1,268 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mitch000001/fitbit-exporter/pkg/http/rate"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Job describes a resource which is scraped periodically.
+type Job struct {
+	// Name identifies the resource and is used as metric label.
+	Name string
+	// Interval is the base time between two runs of the job. The planner
+	// stretches or shrinks it according to the rate limit budget.
+	Interval time.Duration
+	// Jitter is the maximum random delay added to each run to avoid all jobs
+	// firing at the same time.
+	Jitter time.Duration
+	// Scope is the OAuth scope required by the job. The job is skipped as
+	// long as the scope is not granted. An empty scope is always granted.
+	Scope string
+	// Cost is the number of requests a run of the job sends. Defaults to 1.
+	Cost int
+	// Priority is the priority of the requests sent by the job when the rate
+	// limit budget is tight.
+	Priority rate.Priority
+	// Run scrapes the resource.
+	Run func(ctx context.Context) error
+}
+
+func (j Job) cost() int {
+	if j.Cost < 1 {
+		return 1
+	}
+	return j.Cost
+}
+
+// ScopeChecker reports whether an OAuth scope is granted.
+type ScopeChecker func(scope string) bool
+
+// Scheduler runs the registered jobs concurrently, each in its own interval.
+// It is a prometheus.Collector exposing the duration and success of the runs.
+type Scheduler struct {
+	hasScope ScopeChecker
+	planner  *Planner
+	mutex    sync.Mutex
+	jobs     []Job
+	states   map[string]*jobState
+
+	duration    *prometheus.GaugeVec
+	success     *prometheus.GaugeVec
+	lastSuccess *prometheus.GaugeVec
+}
+
+type jobState struct {
+	interval  time.Duration
+	lastRun   time.Time
+	nextRun   time.Time
+	lastError error
+}
+
+// New returns a Scheduler checking the scopes of the jobs with hasScope. If
+// hasScope is nil all scopes are treated as granted. If planner is nil the
+// jobs are run in their base interval.
+func New(hasScope ScopeChecker, planner *Planner) *Scheduler {
+	if hasScope == nil {
+		hasScope = func(string) bool { return true }
+	}
+	return &Scheduler{
+		hasScope: hasScope,
+		planner:  planner,
+		states:   make(map[string]*jobState),
+		duration: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "fitbit",
+			Name:      "scrape_duration_seconds",
+			Help:      "The duration of the last scrape of the resource in seconds.",
+		}, []string{"resource"}),
+		success: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "fitbit",
+			Name:      "scrape_success",
+			Help:      "Whether the last scrape of the resource succeeded.",
+		}, []string{"resource"}),
+		lastSuccess: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "fitbit",
+			Name:      "scrape_last_success_timestamp_seconds",
+			Help:      "The time of the last successful scrape of the resource as unix timestamp.",
+		}, []string{"resource"}),
+	}
+}
+
+// Register adds the job to the scheduler. Jobs registered after Run has been
+// called are not run.
+func (s *Scheduler) Register(job Job) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.jobs = append(s.jobs, job)
+	s.states[job.Name] = &jobState{interval: job.Interval}
+}
+
+// Run runs all registered jobs until the context is done. It blocks until
+// all running jobs have returned.
+func (s *Scheduler) Run(ctx context.Context) {
+	s.mutex.Lock()
+	jobs := make([]Job, len(s.jobs))
+	copy(jobs, s.jobs)
+	s.mutex.Unlock()
+	var wg sync.WaitGroup
+	for _, job := range jobs {
+		wg.Add(1)
+		go func(job Job) {
+			defer wg.Done()
+			s.runJob(ctx, job)
+		}(job)
+	}
+	wg.Wait()
+}
+
+func (s *Scheduler) runJob(ctx context.Context, job Job) {
+	delay := jitter(job.Jitter)
+	s.setNextRun(job, time.Now().Add(delay))
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			s.runOnce(ctx, job)
+			delay := s.interval(job) + jitter(job.Jitter)
+			s.setNextRun(job, time.Now().Add(delay))
+			timer.Reset(delay)
+		}
+	}
+}
+
+func (s *Scheduler) runOnce(ctx context.Context, job Job) {
+	if job.Scope != "" && !s.hasScope(job.Scope) {
+		log.Printf("Skipping scrape of %s: scope %s not granted", job.Name, job.Scope)
+		return
+	}
+	start := time.Now()
+	err := job.Run(rate.WithPriority(ctx, job.Priority))
+	s.duration.WithLabelValues(job.Name).Set(time.Since(start).Seconds())
+	s.mutex.Lock()
+	s.states[job.Name].lastRun = start
+	s.states[job.Name].lastError = err
+	s.mutex.Unlock()
+	if err != nil {
+		log.Printf("Error scraping %s: %v", job.Name, err)
+		s.success.WithLabelValues(job.Name).Set(0)
+		return
+	}
+	s.success.WithLabelValues(job.Name).Set(1)
+	s.lastSuccess.WithLabelValues(job.Name).SetToCurrentTime()
+}
+
+// interval returns the planned interval of the job.
+func (s *Scheduler) interval(job Job) time.Duration {
+	interval := job.Interval
+	if s.planner != nil {
+		factor, wait := s.planner.Factor(s.demand(), time.Now())
+		interval = time.Duration(float64(job.Interval) * factor)
+		if interval < wait {
+			interval = wait
+		}
+	}
+	s.mutex.Lock()
+	s.states[job.Name].interval = interval
+	s.mutex.Unlock()
+	return interval
+}
+
+// demand returns the requests per second of all jobs with granted scopes
+// when run in their base interval.
+func (s *Scheduler) demand() float64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	var demand float64
+	for _, job := range s.jobs {
+		if job.Interval <= 0 || (job.Scope != "" && !s.hasScope(job.Scope)) {
+			continue
+		}
+		demand += float64(job.cost()) / job.Interval.Seconds()
+	}
+	return demand
+}
+
+func (s *Scheduler) setNextRun(job Job, next time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.states[job.Name].nextRun = next
+}
+
+// Plan describes the current schedule of all jobs.
+type Plan struct {
+	Budget    Budget
+	Factor    float64
+	Resources []PlannedResource
+}
+
+// PlannedResource describes the schedule of a single job.
+type PlannedResource struct {
+	Name         string
+	Scope        string
+	Cost         int
+	BaseInterval time.Duration
+	Interval     time.Duration
+	LastRun      time.Time
+	NextRun      time.Time
+	LastError    string
+}
+
+// Plan returns the current schedule sorted by the next run.
+func (s *Scheduler) Plan() Plan {
+	now := time.Now()
+	plan := Plan{Factor: 1}
+	if s.planner != nil {
+		plan.Budget = s.planner.Budget(now)
+		plan.Factor, _ = s.planner.Factor(s.demand(), now)
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, job := range s.jobs {
+		state := s.states[job.Name]
+		resource := PlannedResource{
+			Name:         job.Name,
+			Scope:        job.Scope,
+			Cost:         job.cost(),
+			BaseInterval: job.Interval,
+			Interval:     state.interval,
+			LastRun:      state.lastRun,
+			NextRun:      state.nextRun,
+		}
+		if state.lastError != nil {
+			resource.LastError = state.lastError.Error()
+		}
+		plan.Resources = append(plan.Resources, resource)
+	}
+	sort.Slice(plan.Resources, func(i, j int) bool {
+		return plan.Resources[i].NextRun.Before(plan.Resources[j].NextRun)
+	})
+	return plan
+}
+
+func (s *Scheduler) Describe(ch chan<- *prometheus.Desc) {
+	s.duration.Describe(ch)
+	s.success.Describe(ch)
+	s.lastSuccess.Describe(ch)
+}
+
+func (s *Scheduler) Collect(ch chan<- prometheus.Metric) {
+	s.duration.Collect(ch)
+	s.success.Collect(ch)
+	s.lastSuccess.Collect(ch)
+}
+
+func jitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(max)))
+}
@@ -0,0 +1,56 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mitch000001/fitbit-exporter/pkg/http/rate"
+)
+
+func TestSchedulerDemand(t *testing.T) {
+	s := New(func(scope string) bool { return scope != "nutrition" }, nil)
+	s.Register(Job{Name: "heart_rate", Interval: time.Minute, Scope: "heartrate"})
+	s.Register(Job{Name: "workouts", Interval: 2 * time.Minute, Scope: "activity", Cost: 4})
+	s.Register(Job{Name: "food_log", Interval: time.Minute, Scope: "nutrition"})
+
+	// One request per minute and four requests every two minutes. The jobs
+	// without granted scope do not count.
+	if demand, expected := s.demand(), 3.0/60; demand != expected {
+		t.Errorf("expected a demand of %v requests per second, got %v", expected, demand)
+	}
+	costs := make(map[string]int)
+	for _, resource := range s.Plan().Resources {
+		costs[resource.Name] = resource.Cost
+	}
+	if costs["heart_rate"] != 1 || costs["workouts"] != 4 {
+		t.Errorf("expected costs of 1 and 4, got %v", costs)
+	}
+}
+
+func TestSchedulerRunOnce(t *testing.T) {
+	s := New(func(scope string) bool { return scope == "heartrate" }, nil)
+	var priority rate.Priority
+	runs := 0
+	granted := Job{Name: "heart_rate", Scope: "heartrate", Priority: rate.PriorityHigh, Run: func(ctx context.Context) error {
+		runs++
+		priority = rate.PriorityFromContext(ctx)
+		return nil
+	}}
+	skipped := Job{Name: "sleep", Scope: "sleep", Run: func(context.Context) error {
+		t.Error("expected the job without granted scope to be skipped")
+		return nil
+	}}
+	s.Register(granted)
+	s.Register(skipped)
+
+	s.runOnce(context.Background(), granted)
+	s.runOnce(context.Background(), skipped)
+
+	if runs != 1 {
+		t.Errorf("expected 1 run, got %d", runs)
+	}
+	if priority != rate.PriorityHigh {
+		t.Errorf("expected the requests to be sent with priority %v, got %v", rate.PriorityHigh, priority)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mitch000001/fitbit-exporter/pkg/fitbit"
+)
+
+// userProfile holds the profile of an enrolled user.
+type userProfile struct {
+	client  *fitbit.Client
+	mutex   sync.Mutex
+	profile *fitbit.Profile
+}
+
+func newUserProfile(client *fitbit.Client) *userProfile {
+	return &userProfile{
+		client: client,
+	}
+}
+
+// Load fetches the profile and updates the profile collector.
+func (u *userProfile) Load(ctx context.Context) error {
+	profile, err := u.client.Profile(ctx)
+	if err != nil {
+		return fmt.Errorf("error loading profile: %w", err)
+	}
+	profileCollector.Update(*profile)
+	u.mutex.Lock()
+	u.profile = profile
+	u.mutex.Unlock()
+	return nil
+}
+
+// Profile returns the loaded profile or nil if it is not yet loaded.
+func (u *userProfile) Profile() *fitbit.Profile {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+	return u.profile
+}
+
+// Location returns the timezone of the user, or the local timezone if the
+// profile is not yet loaded.
+func (u *userProfile) Location() *time.Location {
+	profile := u.Profile()
+	if profile == nil {
+		return time.Local
+	}
+	return profile.Location()
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mitch000001/fitbit-exporter/pkg/fitbit"
+	"github.com/mitch000001/fitbit-exporter/pkg/http/rate"
+	"github.com/mitch000001/fitbit-exporter/pkg/scheduler"
+)
+
+// scrapeConfig contains the settings of the fetched Fitbit resources.
+type scrapeConfig struct {
+	intradayDetailLevel fitbit.DetailLevel
+	workoutLimit        int
+	leaderboardFriends  []string
+}
+
+// scraper fetches the Fitbit resources and updates the collectors.
+type scraper struct {
+	client     *fitbit.Client
+	profile    *userProfile
+	collectors *healthCollectors
+	conf       scrapeConfig
+}
+
+// jobs returns a scheduler job for every scraped resource. The intervals are
+// chosen to stay well below the rate limit of 150 requests per hour. The
+// profile is fetched without jitter as other jobs depend on it. When the rate
+// limit budget is tight the intraday heart rate is preferred and the lifetime
+// stats are deferred. Every job sends a single request, except for the
+// workouts which follow the pagination of the activity log list.
+func (s *scraper) jobs() []scheduler.Job {
+	jobs := []scheduler.Job{
+		{Name: "profile", Interval: time.Hour, Scope: "profile", Run: s.profile.Load},
+		{Name: "heart_rate", Interval: 5 * time.Minute, Jitter: 10 * time.Second, Scope: "heartrate", Priority: rate.PriorityHigh, Run: s.scrapeHeartRate},
+		{Name: "heart_rate_daily", Interval: 15 * time.Minute, Jitter: 30 * time.Second, Scope: "heartrate", Run: s.scrapeHeartRateDaily},
+		{Name: "active_zone_minutes", Interval: 15 * time.Minute, Jitter: 30 * time.Second, Scope: "activity", Run: s.scrapeActiveZoneMinutes},
+		{Name: "active_zone_minutes_intraday", Interval: 15 * time.Minute, Jitter: 30 * time.Second, Scope: "activity", Run: s.scrapeActiveZoneMinutesIntraday},
+		{Name: "sleep", Interval: time.Hour, Jitter: time.Minute, Scope: "sleep", Run: s.scrapeSleep},
+		{Name: "activity", Interval: 10 * time.Minute, Jitter: 30 * time.Second, Scope: "activity", Run: s.scrapeActivity},
+		{Name: "weight", Interval: time.Hour, Jitter: time.Minute, Scope: "weight", Run: s.scrapeWeight},
+		{Name: "body_fat", Interval: time.Hour, Jitter: time.Minute, Scope: "weight", Run: s.scrapeBodyFat},
+		{Name: "devices", Interval: 15 * time.Minute, Jitter: 30 * time.Second, Scope: "settings", Run: s.scrapeDevices},
+		{Name: "food_log", Interval: 30 * time.Minute, Jitter: time.Minute, Scope: "nutrition", Run: s.scrapeFoodLog},
+		{Name: "water_log", Interval: 30 * time.Minute, Jitter: time.Minute, Scope: "nutrition", Run: s.scrapeWaterLog},
+		{Name: "water_goal", Interval: 6 * time.Hour, Jitter: time.Minute, Scope: "nutrition", Run: s.scrapeWaterGoal},
+		{Name: "spo2", Interval: time.Hour, Jitter: time.Minute, Scope: "oxygen_saturation", Run: s.scrapeSpO2},
+		{Name: "heart_rate_variability", Interval: time.Hour, Jitter: time.Minute, Scope: "heartrate", Run: s.scrapeHeartRateVariability},
+		{Name: "breathing_rate", Interval: time.Hour, Jitter: time.Minute, Scope: "respiratory_rate", Run: s.scrapeBreathingRate},
+		{Name: "skin_temperature", Interval: time.Hour, Jitter: time.Minute, Scope: "temperature", Run: s.scrapeSkinTemperature},
+		{Name: "cardio_fitness", Interval: 6 * time.Hour, Jitter: time.Minute, Scope: "cardio_fitness", Run: s.scrapeCardioFitness},
+		{Name: "workouts", Interval: 30 * time.Minute, Jitter: time.Minute, Scope: "activity", Cost: fitbit.RecentActivityLogsRequests(s.conf.workoutLimit), Run: s.scrapeWorkouts},
+		{Name: "leaderboard", Interval: time.Hour, Jitter: time.Minute, Scope: "social", Run: s.scrapeLeaderboard},
+		{Name: "lifetime_stats", Interval: 6 * time.Hour, Jitter: time.Minute, Scope: "activity", Priority: rate.PriorityLow, Run: s.scrapeLifetimeStats},
+	}
+	for _, resource := range fitbit.IntradayResources {
+		jobs = append(jobs, scheduler.Job{
+			Name:     fmt.Sprintf("intraday_%s", resource),
+			Interval: 15 * time.Minute,
+			Jitter:   30 * time.Second,
+			Scope:    "activity",
+			Run:      s.intradayScraper(resource),
+		})
+	}
+	return jobs
+}
+
+// now returns the current time within the timezone of the user. Fitbit
+// resources are fetched by date, so "today" has to be the day of the user.
+func (s *scraper) now() time.Time {
+	return time.Now().In(s.profile.Location())
+}
+
+// intradayWindow returns the time window of intraday time series to fetch,
+// which starts at the beginning of the current hour of the user.
+func intradayWindow(now time.Time) (time.Time, time.Time) {
+	return time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), 0, 0, 0, now.Location()), now
+}
+
+// activityWindow returns the time window of intraday time series to fetch
+// whose completed intervals are exported. It reaches back an hour, so the
+// intervals completed after the previous fetch are included even if the hour
+// has changed since. It never reaches back beyond midnight.
+func activityWindow(now time.Time) (time.Time, time.Time) {
+	from := now.Add(-time.Hour)
+	if midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()); from.Before(midnight) {
+		from = midnight
+	}
+	start, _ := intradayWindow(from)
+	return start, now
+}
+
+func (s *scraper) scrapeHeartRate(ctx context.Context) error {
+	now := s.now()
+	start, end := intradayWindow(now)
+	heartRates, err := s.client.HeartRateIntraday(ctx, now, fitbit.DetailLevel1Sec, start, end)
+	if err != nil {
+		return err
+	}
+	s.collectors.heartRate.Update(*heartRates)
+	return nil
+}
+
+func (s *scraper) scrapeHeartRateDaily(ctx context.Context) error {
+	daily, err := s.client.HeartRateByDate(ctx, s.now(), fitbit.Period1Day)
+	if err != nil {
+		return err
+	}
+	s.collectors.heartRate.UpdateDaily(*daily)
+	return nil
+}
+
+func (s *scraper) scrapeActiveZoneMinutes(ctx context.Context) error {
+	daily, err := s.client.ActiveZoneMinutesByDate(ctx, s.now())
+	if err != nil {
+		return err
+	}
+	s.collectors.activeZoneMinutes.UpdateDaily(*daily)
+	return nil
+}
+
+func (s *scraper) scrapeActiveZoneMinutesIntraday(ctx context.Context) error {
+	now := s.now()
+	start, end := activityWindow(now)
+	intraday, err := s.client.ActiveZoneMinutesIntraday(ctx, now, s.conf.intradayDetailLevel, start, end)
+	if err != nil {
+		return err
+	}
+	s.collectors.activeZoneMinutes.UpdateIntraday(*intraday, s.conf.intradayDetailLevel, now)
+	return nil
+}
+
+func (s *scraper) scrapeSleep(ctx context.Context) error {
+	sleep, err := s.client.SleepByDate(ctx, s.now())
+	if err != nil {
+		return err
+	}
+	s.collectors.sleep.Update(*sleep, s.profile.Location())
+	return nil
+}
+
+func (s *scraper) scrapeActivity(ctx context.Context) error {
+	activity, err := s.client.ActivitySummaryByDate(ctx, s.now())
+	if err != nil {
+		return err
+	}
+	s.collectors.activity.Update(*activity)
+	return nil
+}
+
+func (s *scraper) intradayScraper(resource fitbit.IntradayResource) func(context.Context) error {
+	return func(ctx context.Context) error {
+		now := s.now()
+		start, end := activityWindow(now)
+		result, err := s.client.IntradayTimeSeries(ctx, resource, now, s.conf.intradayDetailLevel, start, end)
+		if err != nil {
+			return err
+		}
+		s.collectors.intraday.Update(*result, now)
+		return nil
+	}
+}
+
+func (s *scraper) scrapeWeight(ctx context.Context) error {
+	profile := s.profile.Profile()
+	if profile == nil {
+		return fmt.Errorf("profile not yet loaded")
+	}
+	weight, err := s.client.WeightLogs(ctx, fitbit.UnitSystem(profile.WeightUnit), s.now(), fitbit.Period1Month)
+	if err != nil {
+		return err
+	}
+	s.collectors.body.UpdateWeight(*weight)
+	return nil
+}
+
+func (s *scraper) scrapeBodyFat(ctx context.Context) error {
+	bodyFat, err := s.client.BodyFatLogs(ctx, s.now(), fitbit.Period1Month)
+	if err != nil {
+		return err
+	}
+	s.collectors.body.UpdateBodyFat(*bodyFat)
+	return nil
+}
+
+func (s *scraper) scrapeDevices(ctx context.Context) error {
+	devices, err := s.client.Devices(ctx)
+	if err != nil {
+		return err
+	}
+	s.collectors.devices.Update(devices, s.profile.Location())
+	return nil
+}
+
+func (s *scraper) scrapeFoodLog(ctx context.Context) error {
+	foodLog, err := s.client.FoodLogByDate(ctx, s.now())
+	if err != nil {
+		return err
+	}
+	s.collectors.nutrition.UpdateFoodLog(*foodLog)
+	return nil
+}
+
+func (s *scraper) scrapeWaterLog(ctx context.Context) error {
+	waterLog, err := s.client.WaterLogByDate(ctx, s.now())
+	if err != nil {
+		return err
+	}
+	s.collectors.nutrition.UpdateWaterLog(*waterLog)
+	return nil
+}
+
+func (s *scraper) scrapeWaterGoal(ctx context.Context) error {
+	waterGoal, err := s.client.WaterGoal(ctx)
+	if err != nil {
+		return err
+	}
+	s.collectors.nutrition.UpdateWaterGoal(*waterGoal)
+	return nil
+}
+
+func (s *scraper) scrapeSpO2(ctx context.Context) error {
+	spo2, err := s.client.SpO2ByDate(ctx, s.now())
+	if err != nil {
+		return err
+	}
+	s.collectors.health.UpdateSpO2(*spo2)
+	return nil
+}
+
+func (s *scraper) scrapeHeartRateVariability(ctx context.Context) error {
+	hrv, err := s.client.HeartRateVariabilityByDate(ctx, s.now())
+	if err != nil {
+		return err
+	}
+	s.collectors.health.UpdateHeartRateVariability(*hrv)
+	return nil
+}
+
+func (s *scraper) scrapeBreathingRate(ctx context.Context) error {
+	breathingRate, err := s.client.BreathingRateByDate(ctx, s.now())
+	if err != nil {
+		return err
+	}
+	s.collectors.health.UpdateBreathingRate(*breathingRate)
+	return nil
+}
+
+func (s *scraper) scrapeSkinTemperature(ctx context.Context) error {
+	skinTemperature, err := s.client.SkinTemperatureByDate(ctx, s.now())
+	if err != nil {
+		return err
+	}
+	s.collectors.health.UpdateSkinTemperature(*skinTemperature)
+	return nil
+}
+
+func (s *scraper) scrapeCardioFitness(ctx context.Context) error {
+	cardioFitness, err := s.client.CardioFitnessByDate(ctx, s.now())
+	if err != nil {
+		return err
+	}
+	s.collectors.health.UpdateCardioFitness(*cardioFitness)
+	return nil
+}
+
+func (s *scraper) scrapeWorkouts(ctx context.Context) error {
+	activities, err := s.client.RecentActivityLogs(ctx, s.now().AddDate(0, 0, 1), s.conf.workoutLimit)
+	if err != nil {
+		return err
+	}
+	s.collectors.workouts.Update(activities)
+	return nil
+}
+
+func (s *scraper) scrapeLeaderboard(ctx context.Context) error {
+	leaderboard, err := s.client.FriendsLeaderboard(ctx)
+	if err != nil {
+		return err
+	}
+	s.collectors.leaderboard.Update(*leaderboard)
+	return nil
+}
+
+func (s *scraper) scrapeLifetimeStats(ctx context.Context) error {
+	stats, err := s.client.LifetimeStats(ctx)
+	if err != nil {
+		return err
+	}
+	s.collectors.lifetime.Update(*stats, s.profile.Location())
+	return nil
+}
@@ -0,0 +1,336 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/mitch000001/fitbit-exporter/pkg/fitbit"
+	"github.com/mitch000001/fitbit-exporter/pkg/http/oauth"
+	"github.com/mitch000001/fitbit-exporter/pkg/http/rate"
+	"github.com/mitch000001/fitbit-exporter/pkg/scheduler"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/oauth2"
+)
+
+// rateLimitConfig contains the rate limit settings applied to every user.
+type rateLimitConfig struct {
+	headerKeys rate.HeaderKeys
+	reserve    float64
+	dropLow    bool
+	// stateDir is the directory to persist the rate limit state of every
+	// user in. The state is not persisted if empty.
+	stateDir string
+}
+
+// user holds everything needed to export the data of a single Fitbit account.
+type user struct {
+	id        string
+	conf      *oauth.Config
+	profile   *userProfile
+	scheduler *scheduler.Scheduler
+	// collectors are registered once the user is enrolled.
+	collectors []prometheus.Collector
+	running    bool
+}
+
+// userRegistry holds all enrolled users. As Fitbit limits the requests per
+// user, every user gets its own token cache entry, rate limiter, collectors
+// and scheduler. All metrics of a user are labelled with its user ID.
+type userRegistry struct {
+	oauthConfig *oauth.Config
+	tokenStore  oauth.TokenStore
+	rateLimit   rateLimitConfig
+	scrapeConf  scrapeConfig
+	registerer  prometheus.Registerer
+	// apiBaseURL overrides the base URL of the Fitbit API if not empty.
+	apiBaseURL string
+
+	mutex sync.Mutex
+	users map[string]*user
+	ctx   context.Context
+	wg    sync.WaitGroup
+}
+
+func newUserRegistry(oauthConfig *oauth.Config, tokenStore oauth.TokenStore, rateLimit rateLimitConfig, scrapeConf scrapeConfig, registerer prometheus.Registerer) *userRegistry {
+	return &userRegistry{
+		oauthConfig: oauthConfig,
+		tokenStore:  tokenStore,
+		rateLimit:   rateLimit,
+		scrapeConf:  scrapeConf,
+		registerer:  registerer,
+		users:       make(map[string]*user),
+	}
+}
+
+// Load adds all users with a token within the token store.
+func (r *userRegistry) Load() error {
+	userIDs, err := r.tokenStore.UserIDs()
+	if err != nil {
+		return fmt.Errorf("error listing users: %w", err)
+	}
+	for _, userID := range userIDs {
+		if _, err := r.add(userID, nil, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadTokenFile adds the user of the token within the token file. The token
+// file is kept as token cache of the user.
+func (r *userRegistry) LoadTokenFile(ctx context.Context, filePath string) error {
+	cache, err := oauth.NewJSONFileTokenCache(filePath)
+	if err != nil {
+		return fmt.Errorf("error initializing token cache: %w", err)
+	}
+	tok, err := cache.Token()
+	if err != nil {
+		return fmt.Errorf("error getting token from cache: %w", err)
+	}
+	if tok == nil {
+		return nil
+	}
+	userID, tok, err := r.userID(ctx, tok)
+	if err != nil {
+		return err
+	}
+	r.mutex.Lock()
+	_, enrolled := r.users[userID]
+	r.mutex.Unlock()
+	if enrolled {
+		return nil
+	}
+	_, err = r.add(userID, cache, tok)
+	return err
+}
+
+// Enroll adds the user the token has been issued for. If the user is already
+// enrolled its token is replaced.
+func (r *userRegistry) Enroll(ctx context.Context, tok *oauth2.Token) error {
+	userID, tok, err := r.userID(ctx, tok)
+	if err != nil {
+		return err
+	}
+	u, err := r.add(userID, nil, tok)
+	if err != nil {
+		return err
+	}
+	if err := u.profile.Load(ctx); err != nil {
+		log.Printf("Error loading profile of user %s: %v", userID, err)
+	}
+	log.Printf("Enrolled user %s", userID)
+	return nil
+}
+
+// userID returns the ID of the user the token has been issued for. If the
+// token response did not contain it, the profile is fetched. As this may
+// refresh the token, the current token is returned as well.
+func (r *userRegistry) userID(ctx context.Context, tok *oauth2.Token) (string, *oauth2.Token, error) {
+	if userID := oauth.UserID(tok); userID != "" {
+		return userID, tok, nil
+	}
+	conf := &oauth.Config{Config: r.oauthConfig.Config}
+	if err := conf.SetToken(tok); err != nil {
+		return "", nil, err
+	}
+	profile, err := fitbit.NewClient(conf).Profile(ctx)
+	if err != nil {
+		return "", nil, fmt.Errorf("error fetching profile to identify user: %w", err)
+	}
+	tok, err = conf.Token()
+	if err != nil {
+		return "", nil, fmt.Errorf("error getting token: %w", err)
+	}
+	return profile.EncodedID, tok, nil
+}
+
+// add returns the user with the ID and creates it if not yet enrolled. The
+// token of a new user is read from the cache, or from the token store if the
+// cache is nil. If tok is not nil it replaces the token of the user.
+func (r *userRegistry) add(userID string, cache oauth.TokenCache, tok *oauth2.Token) (*user, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if u, ok := r.users[userID]; ok {
+		if tok == nil {
+			return u, nil
+		}
+		if err := u.conf.SetToken(tok); err != nil {
+			return nil, fmt.Errorf("error setting token of user %s: %w", userID, err)
+		}
+		return u, nil
+	}
+	if cache == nil {
+		var err error
+		cache, err = r.tokenStore.TokenCache(userID)
+		if err != nil {
+			return nil, fmt.Errorf("error initializing token cache of user %s: %w", userID, err)
+		}
+	}
+	u, err := r.newUser(userID, cache)
+	if err != nil {
+		return nil, err
+	}
+	if tok != nil {
+		if err := u.conf.SetToken(tok); err != nil {
+			return nil, fmt.Errorf("error setting token of user %s: %w", userID, err)
+		}
+	}
+	if err := r.register(u); err != nil {
+		return nil, err
+	}
+	r.users[userID] = u
+	if r.ctx != nil {
+		r.start(u)
+	}
+	return u, nil
+}
+
+func (r *userRegistry) newUser(userID string, cache oauth.TokenCache) (*user, error) {
+	rl, err := rate.NewFromHeader(r.rateLimit.headerKeys)
+	if err != nil {
+		return nil, fmt.Errorf("error initializing rate limiter of user %s: %w", userID, err)
+	}
+	rl.SetPriorityOptions(rate.PriorityOptions{
+		Reserve: r.rateLimit.reserve,
+		DropLow: r.rateLimit.dropLow,
+		OnDeferred: func(p rate.Priority) {
+			rateLimiterDeferredCounter.WithLabelValues(userID, p.String()).Inc()
+		},
+		OnDropped: func(p rate.Priority) {
+			rateLimiterDroppedCounter.WithLabelValues(userID, p.String()).Inc()
+		},
+	})
+	for _, p := range rate.Priorities {
+		rateLimiterDeferredCounter.WithLabelValues(userID, p.String())
+		rateLimiterDroppedCounter.WithLabelValues(userID, p.String())
+	}
+	rateLimitedCounter.WithLabelValues(userID)
+	planner := scheduler.NewPlanner(r.rateLimit.reserve)
+	if r.rateLimit.stateDir != "" {
+		stateStore, err := rate.NewJSONFileStateStore(filepath.Join(r.rateLimit.stateDir, userID+".json"))
+		if err != nil {
+			return nil, fmt.Errorf("error initializing rate limit state store of user %s: %w", userID, err)
+		}
+		if err := rl.SetStateStore(stateStore); err != nil {
+			return nil, fmt.Errorf("error setting rate limit state store of user %s: %w", userID, err)
+		}
+		if state, _ := stateStore.State(); state != nil && state.ResetAt.After(time.Now()) {
+			planner.Observe(state.LimitAt(time.Now()))
+		}
+	}
+	conf := &oauth.Config{
+		Config:      r.oauthConfig.Config,
+		RateLimiter: rl,
+		RateLimitOptions: rate.TransportOptions{
+			HeaderKeys: r.rateLimit.headerKeys,
+			Retry:      true,
+			OnRateLimited: func(response *http.Response) {
+				rateLimitedCounter.WithLabelValues(userID).Inc()
+				log.Printf("Request of user %s to %s has been rate limited", userID, response.Request.URL.Path)
+			},
+		},
+		InstrumentTransport: instrumentTransport(r.rateLimit.headerKeys, userID, planner.Observe),
+	}
+	if err := conf.SetTokenCache(cache); err != nil {
+		return nil, fmt.Errorf("error setting token cache of user %s: %w", userID, err)
+	}
+
+	client := fitbit.NewClient(conf)
+	if r.apiBaseURL != "" {
+		client.BaseURL = r.apiBaseURL
+	}
+	profile := newUserProfile(client)
+	collectors := newHealthCollectors(r.scrapeConf.leaderboardFriends)
+	s := &scraper{
+		client:     client,
+		profile:    profile,
+		collectors: collectors,
+		conf:       r.scrapeConf,
+	}
+	sched := scheduler.New(conf.HasScope, planner)
+	for _, job := range s.jobs() {
+		sched.Register(job)
+	}
+	return &user{
+		id:         userID,
+		conf:       conf,
+		profile:    profile,
+		scheduler:  sched,
+		collectors: append(collectors.all(), sched),
+	}, nil
+}
+
+// register registers the collectors of the user labelled with its user ID. If
+// any collector fails to register, the ones already registered are
+// unregistered again so the user can be enrolled later on.
+func (r *userRegistry) register(u *user) error {
+	userRegisterer := prometheus.WrapRegistererWith(
+		prometheus.Labels{"user_id": u.id},
+		r.registerer,
+	)
+	for i, c := range u.collectors {
+		if err := userRegisterer.Register(c); err != nil {
+			for _, registered := range u.collectors[:i] {
+				userRegisterer.Unregister(registered)
+			}
+			return fmt.Errorf("error registering collectors of user %s: %w", u.id, err)
+		}
+	}
+	return nil
+}
+
+// start runs the scheduler of the user. It needs to be called with the mutex
+// held.
+func (r *userRegistry) start(u *user) {
+	if u.running || r.ctx.Err() != nil {
+		return
+	}
+	u.running = true
+	log.Printf("Exporting metrics for user %s", u.id)
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		u.scheduler.Run(r.ctx)
+	}()
+}
+
+// Run runs the schedulers of all users, including users enrolled later on,
+// until the context is done. It blocks until all schedulers have returned.
+func (r *userRegistry) Run(ctx context.Context) {
+	r.mutex.Lock()
+	r.ctx = ctx
+	for _, u := range r.users {
+		r.start(u)
+	}
+	r.mutex.Unlock()
+	<-ctx.Done()
+	r.wg.Wait()
+}
+
+// IsAuthorized reports whether at least one user is authorized.
+func (r *userRegistry) IsAuthorized() bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, u := range r.users {
+		if u.conf.IsAuthorized() {
+			return true
+		}
+	}
+	return false
+}
+
+// Plans returns the planned schedule of every user by user ID.
+func (r *userRegistry) Plans() map[string]scheduler.Plan {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	plans := make(map[string]scheduler.Plan, len(r.users))
+	for userID, u := range r.users {
+		plans[userID] = u.scheduler.Plan()
+	}
+	return plans
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mitch000001/fitbit-exporter/pkg/fitbit"
+	"github.com/mitch000001/fitbit-exporter/pkg/http/oauth"
+	"github.com/mitch000001/fitbit-exporter/pkg/http/rate"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/oauth2"
+)
+
+func enrollToken(userID string, n int) *oauth2.Token {
+	tok := &oauth2.Token{
+		AccessToken:  fmt.Sprintf("access-%d", n),
+		RefreshToken: fmt.Sprintf("refresh-%d", n),
+		TokenType:    "Bearer",
+		Expiry:       time.Now().Add(time.Hour),
+	}
+	return tok.WithExtra(map[string]interface{}{
+		"user_id": userID,
+		"scope":   "profile activity heartrate",
+	})
+}
+
+// newTestUserRegistry returns a user registry fetching from a fake Fitbit
+// API and registering its collectors with the registry.
+func newTestUserRegistry(t *testing.T, tokenStore oauth.TokenStore, registry *prometheus.Registry) *userRegistry {
+	t.Helper()
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/1/user/-/profile.json" {
+			fmt.Fprint(w, `{"user":{"encodedId":"ABC123","timezone":"Europe/Berlin"}}`)
+			return
+		}
+		fmt.Fprint(w, `{}`)
+	}))
+	t.Cleanup(api.Close)
+	conf := &oauth.Config{
+		Config: &oauth2.Config{
+			ClientID: "client-id",
+			Endpoint: oauth2.Endpoint{TokenURL: api.URL + "/oauth2/token"},
+		},
+	}
+	users := newUserRegistry(
+		conf,
+		tokenStore,
+		rateLimitConfig{
+			headerKeys: rate.HeaderKeys{
+				LimitKey:       "Fitbit-Rate-Limit-Limit",
+				RemainingKey:   "Fitbit-Rate-Limit-Remaining",
+				ResetsAfterKey: "Fitbit-Rate-Limit-Reset",
+			},
+			reserve: 0.1,
+		},
+		scrapeConfig{intradayDetailLevel: fitbit.DetailLevel1Min, workoutLimit: 10},
+		registry,
+	)
+	users.apiBaseURL = api.URL
+	return users
+}
+
+func TestUserRegistryReEnrollRunningUser(t *testing.T) {
+	tokenStore, err := oauth.NewJSONFileTokenStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	users := newTestUserRegistry(t, tokenStore, prometheus.NewRegistry())
+	if err := users.Enroll(context.Background(), enrollToken("ABC123", 0)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	running := make(chan struct{})
+	go func() {
+		defer close(running)
+		users.Run(ctx)
+	}()
+	// Read the token of the user concurrently, as the scheduler does when
+	// checking the scopes of its jobs.
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				users.IsAuthorized()
+				users.Plans()
+			}
+		}()
+	}
+	const enrollments = 20
+	for i := 1; i <= enrollments; i++ {
+		if err := users.Enroll(context.Background(), enrollToken("ABC123", i)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	wg.Wait()
+	cancel()
+	<-running
+
+	if plans := users.Plans(); len(plans) != 1 {
+		t.Errorf("expected a single user, got %d", len(plans))
+	}
+	cache, err := tokenStore.TokenCache("ABC123")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tok, err := cache.Token()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := fmt.Sprintf("access-%d", enrollments); tok.AccessToken != expected {
+		t.Errorf("expected the latest token %q to be cached, got %q", expected, tok.AccessToken)
+	}
+}
+
+// failingTokenStore returns token caches failing to write tokens while fail is
+// set.
+type failingTokenStore struct {
+	oauth.TokenStore
+	fail bool
+}
+
+func (s *failingTokenStore) TokenCache(userID string) (oauth.TokenCache, error) {
+	cache, err := s.TokenStore.TokenCache(userID)
+	if err != nil {
+		return nil, err
+	}
+	return failingTokenCache{TokenCache: cache, fail: s.fail}, nil
+}
+
+type failingTokenCache struct {
+	oauth.TokenCache
+	fail bool
+}
+
+func (c failingTokenCache) Refresh(tok *oauth2.Token) error {
+	if c.fail {
+		return fmt.Errorf("disk full")
+	}
+	return c.TokenCache.Refresh(tok)
+}
+
+func TestUserRegistryEnrollAfterFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		// fail makes the first enrollment fail and returns a function
+		// resolving the failure.
+		fail func(tokenStore *failingTokenStore, registry *prometheus.Registry) func()
+	}{
+		{
+			name: "token not cached",
+			fail: func(tokenStore *failingTokenStore, _ *prometheus.Registry) func() {
+				tokenStore.fail = true
+				return func() { tokenStore.fail = false }
+			},
+		},
+		{
+			name: "collector not registered",
+			fail: func(_ *failingTokenStore, registry *prometheus.Registry) func() {
+				// Conflicts with the leaderboard, which is registered after
+				// most other collectors of the user.
+				conflicting := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+					Name:        "fitbit_leaderboard_rank",
+					Help:        "The rank of the friend on the 7 day step leaderboard.",
+					ConstLabels: prometheus.Labels{"user_id": "ABC123"},
+				}, []string{"friend_id", "friend"})
+				registry.MustRegister(conflicting)
+				return func() { registry.Unregister(conflicting) }
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fileTokenStore, err := oauth.NewJSONFileTokenStore(t.TempDir())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			tokenStore := &failingTokenStore{TokenStore: fileTokenStore}
+			registry := prometheus.NewRegistry()
+			users := newTestUserRegistry(t, tokenStore, registry)
+			resolve := test.fail(tokenStore, registry)
+
+			if err := users.Enroll(context.Background(), enrollToken("ABC123", 0)); err == nil {
+				t.Fatalf("expected enrolling to fail")
+			}
+			if plans := users.Plans(); len(plans) != 0 {
+				t.Fatalf("expected no user to be enrolled, got %d", len(plans))
+			}
+
+			resolve()
+			if err := users.Enroll(context.Background(), enrollToken("ABC123", 1)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if plans := users.Plans(); len(plans) != 1 {
+				t.Errorf("expected a single user, got %d", len(plans))
+			}
+		})
+	}
+}